@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// walkAST はASTを深さ優先でたどり、各ノードに対してvisitを呼び出す
+// visitがfalseを返した場合はそのノードの子をたどらない
+func walkAST(node proto.Message, visit func(proto.Message) bool) {
+	if node == nil || !node.ProtoReflect().IsValid() {
+		return
+	}
+	if !visit(node) {
+		return
+	}
+
+	// Rangeの順序は不定のため、フィールドの定義順にたどる
+	message := node.ProtoReflect()
+	fields := message.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Message() == nil || field.IsMap() || !message.Has(field) {
+			continue
+		}
+		if field.IsList() {
+			list := message.Get(field).List()
+			for j := 0; j < list.Len(); j++ {
+				walkAST(list.Get(j).Message().Interface(), visit)
+			}
+			continue
+		}
+		walkAST(message.Get(field).Message().Interface(), visit)
+	}
+}
+
+// collectRangeVars はクエリが参照するリレーションを出現順に収集する
+// SELECT INTO の作成先とWITH句で定義された名前への参照は含めない
+func collectRangeVars(node proto.Message) []*pg_query.RangeVar {
+	cteNames := make(map[string]bool)
+	walkAST(node, func(n proto.Message) bool {
+		if cte, ok := n.(*pg_query.CommonTableExpr); ok {
+			cteNames[cte.GetCtename()] = true
+		}
+		return true
+	})
+
+	relations := make([]*pg_query.RangeVar, 0)
+	walkAST(node, func(n proto.Message) bool {
+		switch n := n.(type) {
+		case *pg_query.IntoClause:
+			return false
+		case *pg_query.RangeVar:
+			if n.GetSchemaname() == "" && cteNames[n.GetRelname()] {
+				return false
+			}
+			relations = append(relations, n)
+		}
+		return true
+	})
+	return relations
+}
+
+// columnRefs は式が参照する列を出現順に収集する
+// サブクエリ内の参照は別のテーブルの列を参照するため含めない
+func columnRefs(node proto.Message) []*pg_query.ColumnRef {
+	refs := make([]*pg_query.ColumnRef, 0)
+	walkAST(node, func(n proto.Message) bool {
+		switch n := n.(type) {
+		case *pg_query.SelectStmt:
+			return false
+		case *pg_query.ColumnRef:
+			refs = append(refs, n)
+		}
+		return true
+	})
+	return refs
+}
+
+// parseExpr は式を解析する（空の場合はnil）
+func parseExpr(expr string) (*pg_query.Node, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	tree, err := pg_query.Parse("SELECT " + expr)
+	if err != nil {
+		return nil, err
+	}
+	stmts := tree.GetStmts()
+	if len(stmts) != 1 || len(stmts[0].GetStmt().GetSelectStmt().GetTargetList()) != 1 || len(stmts[0].GetStmt().GetSelectStmt().GetFromClause()) > 0 {
+		return nil, fmt.Errorf("not a single expression: %s", expr)
+	}
+	return stmts[0].GetStmt().GetSelectStmt().GetTargetList()[0].GetResTarget().GetVal(), nil
+}
+
+// typeNameString は型名をSQLでの表記（int4ではなくintなど）に変換する
+func typeNameString(typeName *pg_query.TypeName) string {
+	cast := &pg_query.TypeCast{
+		Arg:      &pg_query.Node{Node: &pg_query.Node_AConst{AConst: &pg_query.A_Const{Isnull: true}}},
+		TypeName: typeName,
+	}
+	sql, err := pg_query.Deparse(&pg_query.ParseResult{
+		Stmts: []*pg_query.RawStmt{{Stmt: &pg_query.Node{Node: &pg_query.Node_SelectStmt{SelectStmt: &pg_query.SelectStmt{
+			TargetList: []*pg_query.Node{{Node: &pg_query.Node_ResTarget{ResTarget: &pg_query.ResTarget{
+				Val: &pg_query.Node{Node: &pg_query.Node_TypeCast{TypeCast: cast}},
+			}}}},
+		}}}}},
+	})
+	if err != nil {
+		return strings.Join(nameList(typeName.GetNames()), ".")
+	}
+	return strings.TrimPrefix(sql, "SELECT NULL::")
+}
+
+// equalExpr は2つの式が位置情報を除いて同じ構造かを確認する
+func equalExpr(a, b *pg_query.Node) bool {
+	return proto.Equal(withoutLocations(a), withoutLocations(b))
+}
+
+// withoutLocations は位置情報（locationフィールド）を0にしたノードの複製を返す
+func withoutLocations(node *pg_query.Node) proto.Message {
+	if node == nil {
+		return nil
+	}
+
+	clone := proto.Clone(node)
+	walkAST(clone, func(n proto.Message) bool {
+		message := n.ProtoReflect()
+		if field := message.Descriptor().Fields().ByName("location"); field != nil && field.Kind() == protoreflect.Int32Kind {
+			message.Clear(field)
+		}
+		return true
+	})
+	return clone
+}
+
+// constantBool は式を定数として評価できる場合にその真偽値を返す
+// true、1 = 1、NOT false、true OR ... などの単純な式のみを畳み込み、列や関数を含む式は評価できないものとする
+func constantBool(node *pg_query.Node) (value bool, ok bool) {
+	switch {
+	case node.GetAConst() != nil:
+		if node.GetAConst().GetBoolval() == nil {
+			return false, false
+		}
+		return node.GetAConst().GetBoolval().GetBoolval(), true
+	case node.GetTypeCast() != nil:
+		return constantBoolCast(node.GetTypeCast())
+	case node.GetBoolExpr() != nil:
+		return constantBoolExpr(node.GetBoolExpr())
+	case node.GetBooleanTest() != nil:
+		return constantBooleanTest(node.GetBooleanTest())
+	case node.GetAExpr() != nil:
+		return constantComparison(node.GetAExpr())
+	}
+	return false, false
+}
+
+// constantBoolCast は 't'::boolean のような真偽値への型変換を評価する
+func constantBoolCast(cast *pg_query.TypeCast) (bool, bool) {
+	names := nameList(cast.GetTypeName().GetNames())
+	if len(names) == 0 || (names[len(names)-1] != "bool" && names[len(names)-1] != "boolean") {
+		return false, false
+	}
+	if value, ok := constantBool(cast.GetArg()); ok {
+		return value, true
+	}
+	if cast.GetArg().GetAConst().GetSval() == nil {
+		return false, false
+	}
+
+	return parseBoolString(cast.GetArg().GetAConst().GetSval().GetSval())
+}
+
+// parseBoolString はPostgreSQLの真偽値の入力形式（省略形を含む）の文字列を評価する
+func parseBoolString(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "tru", "tr", "t", "yes", "ye", "y", "on", "1":
+		return true, true
+	case "false", "fals", "fal", "fa", "f", "no", "n", "off", "of", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// constantBoolExpr はAND / OR / NOTを評価する
+// AND はいずれかが偽なら偽、OR はいずれかが真なら真として、評価できない要素があっても畳み込む
+func constantBoolExpr(expr *pg_query.BoolExpr) (bool, bool) {
+	switch expr.GetBoolop() {
+	case pg_query.BoolExprType_NOT_EXPR:
+		if len(expr.GetArgs()) != 1 {
+			return false, false
+		}
+		value, ok := constantBool(expr.GetArgs()[0])
+		return !value, ok
+	case pg_query.BoolExprType_AND_EXPR, pg_query.BoolExprType_OR_EXPR:
+		// ANDの場合は偽、ORの場合は真の要素があれば全体の値が決まる
+		decisive := expr.GetBoolop() == pg_query.BoolExprType_OR_EXPR
+		known := true
+		for _, arg := range expr.GetArgs() {
+			value, ok := constantBool(arg)
+			if ok && value == decisive {
+				return decisive, true
+			}
+			known = known && ok
+		}
+		if !known {
+			return false, false
+		}
+		return !decisive, true
+	}
+	return false, false
+}
+
+// constantBooleanTest は IS TRUE / IS NOT FALSE などを評価する
+func constantBooleanTest(test *pg_query.BooleanTest) (bool, bool) {
+	value, ok := constantBool(test.GetArg())
+	if !ok {
+		return false, false
+	}
+
+	switch test.GetBooltesttype() {
+	case pg_query.BoolTestType_IS_TRUE, pg_query.BoolTestType_IS_NOT_FALSE:
+		return value, true
+	case pg_query.BoolTestType_IS_FALSE, pg_query.BoolTestType_IS_NOT_TRUE:
+		return !value, true
+	}
+	return false, false
+}
+
+// constantComparison は 1 = 1 や 'a' <> 'b' のような定数同士の比較を評価する
+func constantComparison(expr *pg_query.A_Expr) (bool, bool) {
+	names := nameList(expr.GetName())
+	if expr.GetKind() != pg_query.A_Expr_Kind_AEXPR_OP || len(names) != 1 {
+		return false, false
+	}
+
+	var compare int
+	left, right := expr.GetLexpr().GetAConst(), expr.GetRexpr().GetAConst()
+	if leftNumber, ok := constantNumber(left); ok {
+		rightNumber, ok := constantNumber(right)
+		if !ok {
+			return false, false
+		}
+		switch {
+		case leftNumber < rightNumber:
+			compare = -1
+		case leftNumber > rightNumber:
+			compare = 1
+		}
+	} else if left.GetSval() != nil && right.GetSval() != nil {
+		compare = strings.Compare(left.GetSval().GetSval(), right.GetSval().GetSval())
+	} else {
+		return false, false
+	}
+
+	switch names[0] {
+	case "=":
+		return compare == 0, true
+	case "<>", "!=":
+		return compare != 0, true
+	case "<":
+		return compare < 0, true
+	case "<=":
+		return compare <= 0, true
+	case ">":
+		return compare > 0, true
+	case ">=":
+		return compare >= 0, true
+	}
+	return false, false
+}
+
+// constantNumber は数値の定数の値を返す
+func constantNumber(value *pg_query.A_Const) (float64, bool) {
+	switch {
+	case value.GetIval() != nil:
+		return float64(value.GetIval().GetIval()), true
+	case value.GetFval() != nil:
+		number, err := strconv.ParseFloat(value.GetFval().GetFval(), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectRangeVars(t *testing.T) {
+	testCases := map[string]struct {
+		sql           string
+		expectedNames []string
+	}{
+		"simple select": {
+			sql:           `SELECT * FROM accounts`,
+			expectedNames: []string{"accounts"},
+		},
+		"join and subquery": {
+			sql:           `SELECT * FROM accounts a JOIN auth.users u ON a.id = u.id WHERE a.id IN (SELECT id FROM members)`,
+			expectedNames: []string{"accounts", "auth.users", "members"},
+		},
+		"common table expression": {
+			sql:           `WITH recent AS (SELECT * FROM orders) SELECT * FROM recent`,
+			expectedNames: []string{"orders"},
+		},
+		"select into target": {
+			sql:           `SELECT * INTO tmp FROM accounts`,
+			expectedNames: []string{"accounts"},
+		},
+		"set operation": {
+			sql:           `SELECT id FROM accounts UNION SELECT id FROM archived_accounts`,
+			expectedNames: []string{"accounts", "archived_accounts"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse(tc.sql)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, relation := range collectRangeVars(tree.Stmts[0].Stmt) {
+				names = append(names, QualifiedName{Schema: relation.GetSchemaname(), Name: relation.GetRelname()}.String())
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestConstantBool(t *testing.T) {
+	testCases := map[string]struct {
+		expr          string
+		expectedValue bool
+		expectedOK    bool
+	}{
+		"true":                {expr: `true`, expectedValue: true, expectedOK: true},
+		"false":               {expr: `false`, expectedValue: false, expectedOK: true},
+		"not false":           {expr: `NOT false`, expectedValue: true, expectedOK: true},
+		"integer equality":    {expr: `1 = 1`, expectedValue: true, expectedOK: true},
+		"integer inequality":  {expr: `1 <> 1`, expectedValue: false, expectedOK: true},
+		"mixed numbers":       {expr: `2 > 1.5`, expectedValue: true, expectedOK: true},
+		"string comparison":   {expr: `'a' = 'a'`, expectedValue: true, expectedOK: true},
+		"boolean cast":        {expr: `'yes'::boolean`, expectedValue: true, expectedOK: true},
+		"or with true":        {expr: `tenant_id = 1 OR true`, expectedValue: true, expectedOK: true},
+		"and with false":      {expr: `tenant_id = 1 AND false`, expectedValue: false, expectedOK: true},
+		"and with true":       {expr: `tenant_id = 1 AND true`, expectedOK: false},
+		"boolean test":        {expr: `true IS NOT FALSE`, expectedValue: true, expectedOK: true},
+		"column comparison":   {expr: `tenant_id = tenant_id`, expectedOK: false},
+		"function call":       {expr: `current_user = 'app'`, expectedOK: false},
+		"integer is not bool": {expr: `1`, expectedOK: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse("SELECT " + tc.expr)
+			assert.NoError(t, err)
+
+			expr := tree.Stmts[0].Stmt.GetSelectStmt().GetTargetList()[0].GetResTarget().GetVal()
+			value, ok := constantBool(expr)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func TestEqualExpr(t *testing.T) {
+	testCases := map[string]struct {
+		a, b     string
+		expected bool
+	}{
+		"same expression":         {a: `tenant_id = 1`, b: `tenant_id = 1`, expected: true},
+		"different whitespace":    {a: `tenant_id = 1`, b: `tenant_id   =   1`, expected: true},
+		"different constant":      {a: `tenant_id = 1`, b: `tenant_id = 2`, expected: false},
+		"additional condition":    {a: `tenant_id = 1`, b: `tenant_id = 1 OR is_admin`, expected: false},
+		"same function call":      {a: `tenant_id = current_setting('app.tenant')::int`, b: `tenant_id = current_setting('app.tenant')::int`, expected: true},
+		"different operand order": {a: `tenant_id = 1`, b: `1 = tenant_id`, expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			a, err := pg_query.Parse("SELECT " + tc.a)
+			assert.NoError(t, err)
+			b, err := pg_query.Parse("SELECT " + tc.b)
+			assert.NoError(t, err)
+
+			exprA := a.Stmts[0].Stmt.GetSelectStmt().GetTargetList()[0].GetResTarget().GetVal()
+			exprB := b.Stmts[0].Stmt.GetSelectStmt().GetTargetList()[0].GetResTarget().GetVal()
+			assert.Equal(t, tc.expected, equalExpr(exprA, exprB))
+		})
+	}
+}
+
+func TestColumnRefs(t *testing.T) {
+	testCases := map[string]struct {
+		expr          string
+		expectedNames []string
+	}{
+		"simple comparison": {
+			expr:          `manager = current_user`,
+			expectedNames: []string{"manager"},
+		},
+		"qualified references": {
+			expr:          `accounts.tenant_id = 1 AND public.accounts.owner = 'a'`,
+			expectedNames: []string{"accounts.tenant_id", "public.accounts.owner"},
+		},
+		"subquery is skipped": {
+			expr:          `tenant_id IN (SELECT tenant_id FROM members WHERE user_id = 1)`,
+			expectedNames: []string{"tenant_id"},
+		},
+		"function arguments": {
+			expr:          `has_access(owner_id, (settings).level)`,
+			expectedNames: []string{"owner_id", "settings"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse("SELECT " + tc.expr)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, ref := range columnRefs(tree.Stmts[0].Stmt.GetSelectStmt().GetTargetList()[0].GetResTarget().GetVal()) {
+				names = append(names, strings.Join(nameList(ref.GetFields()), "."))
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	testCases := map[string]struct {
+		expr        string
+		expectNil   bool
+		expectError bool
+	}{
+		"expression":          {expr: `current_setting('app.tenant_id')::uuid`},
+		"empty":               {expr: ` `, expectNil: true},
+		"syntax error":        {expr: `current_setting(`, expectNil: true, expectError: true},
+		"multiple columns":    {expr: `1, 2`, expectNil: true, expectError: true},
+		"multiple statements": {expr: `1; SELECT 2`, expectNil: true, expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			expr, err := parseExpr(tc.expr)
+			assert.Equal(t, tc.expectError, err != nil)
+			assert.Equal(t, tc.expectNil, expr == nil)
+		})
+	}
+}
+
+func TestTypeNameString(t *testing.T) {
+	testCases := map[string]struct {
+		typeName string
+		expected string
+	}{
+		"int":              {typeName: "int", expected: "int"},
+		"integer":          {typeName: "integer", expected: "int"},
+		"qualified int4":   {typeName: "pg_catalog.int4", expected: "int"},
+		"boolean":          {typeName: "boolean", expected: "boolean"},
+		"double precision": {typeName: "double precision", expected: "double precision"},
+		"with modifier":    {typeName: "numeric(10,2)", expected: "numeric(10, 2)"},
+		"array":            {typeName: "uuid[]", expected: "uuid[]"},
+		"user defined":     {typeName: "app.tenant", expected: "app.tenant"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			expr, err := parseExpr("NULL::" + tc.typeName)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, typeNameString(expr.GetTypeCast().GetTypeName()))
+		})
+	}
+}
@@ -7,32 +7,73 @@ import (
 )
 
 // ParseFlags はコマンドラインフラグを解析する
-func ParseFlags() (excludedTables []string, useStdin bool) {
+func ParseFlags() (options LinterOptions, useStdin bool) {
 	var excludedTablesStr string
-	flag.StringVar(&excludedTablesStr, "exclude", "", "Tables to exclude from RLS validation (comma-separated)")
+	var searchPathStr string
+	var forceRLSSchemasStr string
+	var publicTablesStr string
+	var requiredCommandsStr string
+	var appRolesStr string
+	var sensitiveTablesStr string
+	var adminRolesStr string
+	flag.StringVar(&excludedTablesStr, "exclude", "", "Tables to exclude from RLS validation (comma-separated, 'table' or 'schema.table')")
+	flag.StringVar(&searchPathStr, "search-path", strings.Join(DefaultSearchPath, ","), "Default search_path used to resolve unqualified table names (comma-separated)")
+	flag.BoolVar(&options.Rules.InheritExclusion, "inherit-exclusion", false, "Also exclude partitions of excluded tables")
+	flag.BoolVar(&options.Rules.RequireForceRLS, "require-force-rls", false, "Require FORCE ROW LEVEL SECURITY on every RLS-enabled table (rls-not-forced)")
+	flag.StringVar(&forceRLSSchemasStr, "require-force-rls-schemas", "", "Schemas whose RLS-enabled tables require FORCE ROW LEVEL SECURITY (comma-separated)")
+	flag.StringVar(&publicTablesStr, "public-tables", "", "Tables intentionally readable in full, allowed to have always-true policies (comma-separated, 'table' or 'schema.table')")
+	flag.BoolVar(&options.Rules.CheckCommandCoverage, "check-command-coverage", false, "Require a permissive policy for each command on every RLS-enabled table (rls-command-uncovered)")
+	flag.StringVar(&requiredCommandsStr, "require-commands", "", "Commands checked by rls-command-uncovered (comma-separated, default all commands; implies -check-command-coverage)")
+	flag.BoolVar(&options.Rules.RequireWithCheck, "require-with-check", false, "Require an explicit WITH CHECK on UPDATE and ALL policies (rls-policy-missing-with-check)")
+	flag.StringVar(&appRolesStr, "app-roles", "", "Roles the application connects as; each needs a permissive policy on every RLS-enabled table (comma-separated)")
+	flag.StringVar(&sensitiveTablesStr, "sensitive-tables", "", "Tables whose policies must not be granted TO PUBLIC (comma-separated, 'table' or 'schema.table')")
+	flag.BoolVar(&options.Rules.OnlyGrantedTables, "only-granted", false, "Only validate tables on which row privileges are granted (to -app-roles or PUBLIC if set)")
+	flag.StringVar(&adminRolesStr, "admin-roles", "", "Roles allowed to have SUPERUSER or BYPASSRLS (comma-separated)")
+	flag.StringVar(&options.Rules.TenantColumn, "tenant-column", "", "Column every RLS-enabled table and policy must use for tenant isolation (rls-tenant-column)")
+	flag.StringVar(&options.Rules.TenantExpression, "tenant-expr", "", "Expression policies must compare the tenant column to, e.g. \"NULLIF(current_setting('app.tenant_id', true), '')::uuid\"")
 	flag.BoolVar(&useStdin, "stdin", false, "Read SQL from standard input")
 	flag.Parse()
 
 	// 除外テーブルのリスト作成
-	if excludedTablesStr != "" {
-		excludedTables = strings.Split(excludedTablesStr, ",")
+	options.ExcludedTables = splitList(excludedTablesStr)
+	options.SearchPath = splitList(searchPathStr)
+	options.Rules.RequireForceRLSSchemas = splitList(forceRLSSchemasStr)
+	options.Rules.PublicTables = splitList(publicTablesStr)
+	options.Rules.AppRoles = splitList(appRolesStr)
+	options.Rules.SensitiveTables = splitList(sensitiveTablesStr)
+	options.Rules.AdminRoles = splitList(adminRolesStr)
+	options.Rules.RequiredCommands = splitList(strings.ToLower(requiredCommandsStr))
+	if len(options.Rules.RequiredCommands) > 0 {
+		options.Rules.CheckCommandCoverage = true
 	}
 
-	return excludedTables, useStdin
+	return options, useStdin
+}
+
+// splitList はカンマ区切りの文字列を分割する
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // ProcessStdin は標準入力からSQLを読み込んで検証する
-func ProcessStdin(excludedTables []string) error {
-	options := LinterOptions{
-		Sources: []SourceFile{
-			{
-				Reader:   os.Stdin,
-				Filename: "stdin",
-			},
+func ProcessStdin(options LinterOptions) error {
+	options.Sources = []SourceFile{
+		{
+			Reader:   os.Stdin,
+			Filename: "stdin",
 		},
-		Writer:         os.Stdout,
-		ExcludedTables: excludedTables,
 	}
+	options.Writer = os.Stdout
 
 	return RunLinter(options)
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
+)
+
+// PL/pgSQLの式の解析モード（PostgreSQLのRawParseModeに対応）
+const (
+	plpgsqlParseDefault = 0 // SQL文
+	plpgsqlParseExpr    = 2 // 式（IFの条件など）
+	plpgsqlParseAssign1 = 3 // 代入（変数名 := 式）
+	plpgsqlParseAssign3 = 5 // 代入（レコードのフィールドへの代入など）
+)
+
+// functionBody はCREATE FUNCTION / CREATE PROCEDUREの本体に含まれるクエリを抽出する
+// 本体を解析できない言語（C言語など）や解析に失敗した場合はnilを返す
+// EXECUTEによる動的SQLの内容は静的解析では特定できないため含まれない
+func functionBody(stmt *pg_query.CreateFunctionStmt) []*pg_query.Node {
+	// BEGIN ATOMIC ... END / RETURN 形式のSQL標準の本体
+	if body := stmt.GetSqlBody(); body != nil {
+		if body.GetList() == nil {
+			// RETURN 式 の場合
+			return []*pg_query.Node{body}
+		}
+
+		// BEGIN ATOMIC の本体は文のリストを要素とするリストで表される
+		queries := make([]*pg_query.Node, 0)
+		for _, item := range body.GetList().GetItems() {
+			if item.GetList() != nil {
+				queries = append(queries, item.GetList().GetItems()...)
+			} else {
+				queries = append(queries, item)
+			}
+		}
+		return queries
+	}
+
+	switch strings.ToLower(functionOption(stmt, "language").GetString_().GetSval()) {
+	case "sql":
+		return sqlFunctionBody(stmt)
+	case "plpgsql":
+		return plpgsqlFunctionBody(stmt)
+	}
+	return nil
+}
+
+// sqlFunctionBody はLANGUAGE sqlの関数の本体を解析する
+func sqlFunctionBody(stmt *pg_query.CreateFunctionStmt) []*pg_query.Node {
+	items := functionOption(stmt, "as").GetList().GetItems()
+	if len(items) == 0 {
+		return nil
+	}
+
+	tree, err := pg_query.Parse(items[0].GetString_().GetSval())
+	if err != nil {
+		return nil
+	}
+
+	queries := make([]*pg_query.Node, 0, len(tree.GetStmts()))
+	for _, raw := range tree.GetStmts() {
+		queries = append(queries, raw.GetStmt())
+	}
+	return queries
+}
+
+// plpgsqlFunctionBody はLANGUAGE plpgsqlの関数の本体に含まれるSQL文と式を解析する
+func plpgsqlFunctionBody(stmt *pg_query.CreateFunctionStmt) []*pg_query.Node {
+	// PL/pgSQLのパーサーはCREATE FUNCTION文全体を入力とするため、ASTからSQLを再構成する
+	sql, err := pg_query.Deparse(&pg_query.ParseResult{
+		Stmts: []*pg_query.RawStmt{{Stmt: &pg_query.Node{Node: &pg_query.Node_CreateFunctionStmt{CreateFunctionStmt: stmt}}}},
+	})
+	if err != nil {
+		return nil
+	}
+	output, err := pg_query.ParsePlPgSqlToJSON(sql)
+	if err != nil {
+		return nil
+	}
+
+	var functions interface{}
+	if err := json.Unmarshal([]byte(output), &functions); err != nil {
+		return nil
+	}
+
+	queries := make([]*pg_query.Node, 0)
+	for _, expr := range plpgsqlExprs(functions) {
+		if query := parsePlpgsqlExpr(expr); query != nil {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+// plpgsqlExpr はPL/pgSQLの関数に含まれるSQL文または式を表す構造体
+type plpgsqlExpr struct {
+	Query     string `json:"query"`
+	ParseMode int    `json:"parseMode"`
+}
+
+// plpgsqlExprs はPL/pgSQLの解析結果からSQL文と式を収集する
+func plpgsqlExprs(value interface{}) []plpgsqlExpr {
+	exprs := make([]plpgsqlExpr, 0)
+	switch value := value.(type) {
+	case []interface{}:
+		for _, item := range value {
+			exprs = append(exprs, plpgsqlExprs(item)...)
+		}
+	case map[string]interface{}:
+		if expr, ok := value["PLpgSQL_expr"].(map[string]interface{}); ok {
+			query, _ := expr["query"].(string)
+			mode, _ := expr["parseMode"].(float64)
+			return append(exprs, plpgsqlExpr{Query: query, ParseMode: int(mode)})
+		}
+
+		// 結果の順序を一定にするためにキーの順にたどる
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			exprs = append(exprs, plpgsqlExprs(value[key])...)
+		}
+	}
+	return exprs
+}
+
+// parsePlpgsqlExpr はPL/pgSQLのSQL文または式をSQLとして解析する（解析できない場合はnil）
+func parsePlpgsqlExpr(expr plpgsqlExpr) *pg_query.Node {
+	sql := expr.Query
+	switch {
+	case expr.ParseMode == plpgsqlParseDefault:
+	case expr.ParseMode == plpgsqlParseExpr:
+		sql = "SELECT " + sql
+	case expr.ParseMode >= plpgsqlParseAssign1 && expr.ParseMode <= plpgsqlParseAssign3:
+		// 代入先を取り除いて右辺の式のみを解析する
+		index := strings.Index(sql, ":=")
+		if index < 0 {
+			index = strings.Index(sql, "=")
+			if index < 0 {
+				return nil
+			}
+			sql = sql[index+1:]
+		} else {
+			sql = sql[index+2:]
+		}
+		sql = "SELECT " + sql
+	default:
+		return nil
+	}
+
+	tree, err := pg_query.Parse(sql)
+	if err != nil || len(tree.GetStmts()) != 1 {
+		return nil
+	}
+	return tree.GetStmts()[0].GetStmt()
+}
+
+// functionOption はCREATE FUNCTIONのオプションの値を返す（指定がない場合はnil）
+func functionOption(stmt *pg_query.CreateFunctionStmt, name string) *pg_query.Node {
+	for _, option := range stmt.GetOptions() {
+		if option.GetDefElem().GetDefname() == name {
+			return option.GetDefElem().GetArg()
+		}
+	}
+	return nil
+}
+
+// hasRowFilter はクエリがWHERE句で行を絞り込んでいるかを確認する
+// サブクエリを含め、いずれかのSELECT / UPDATE / DELETEがWHERE句を持つ場合に絞り込んでいるとみなす
+func hasRowFilter(query *pg_query.Node) bool {
+	filtered := false
+	walkAST(query, func(n proto.Message) bool {
+		switch n := n.(type) {
+		case *pg_query.SelectStmt:
+			filtered = filtered || n.GetWhereClause() != nil
+		case *pg_query.UpdateStmt:
+			filtered = filtered || n.GetWhereClause() != nil
+		case *pg_query.DeleteStmt:
+			filtered = filtered || n.GetWhereClause() != nil
+		}
+		return !filtered
+	})
+	return filtered
+}
+
+// filterColumns はクエリがWHERE句で参照している列名を出現順に返す（INSERTの場合は挿入先の列を含む）
+// 列名は修飾を除いた名前で、サブクエリのWHERE句も対象にする
+func filterColumns(query *pg_query.Node) []string {
+	columns := make([]string, 0)
+	addRefs := func(where *pg_query.Node) {
+		for _, ref := range columnRefs(where) {
+			fields := ref.GetFields()
+			if len(fields) == 0 || fields[len(fields)-1].GetString_() == nil {
+				continue
+			}
+			columns = append(columns, fields[len(fields)-1].GetString_().GetSval())
+		}
+	}
+	walkAST(query, func(n proto.Message) bool {
+		switch n := n.(type) {
+		case *pg_query.SelectStmt:
+			addRefs(n.GetWhereClause())
+		case *pg_query.UpdateStmt:
+			addRefs(n.GetWhereClause())
+		case *pg_query.DeleteStmt:
+			addRefs(n.GetWhereClause())
+		case *pg_query.InsertStmt:
+			for _, col := range n.GetCols() {
+				columns = append(columns, col.GetResTarget().GetName())
+			}
+		}
+		return true
+	})
+	return columns
+}
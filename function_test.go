@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionBody(t *testing.T) {
+	testCases := map[string]struct {
+		sql           string
+		expectedNames []string
+	}{
+		"sql function": {
+			sql:           `CREATE FUNCTION f() RETURNS SETOF accounts LANGUAGE sql AS $$ SELECT * FROM accounts; DELETE FROM auth.sessions $$`,
+			expectedNames: []string{"accounts", "auth.sessions"},
+		},
+		"begin atomic": {
+			sql:           `CREATE FUNCTION f() RETURNS bigint BEGIN ATOMIC SELECT count(*) FROM accounts; SELECT count(*) FROM members; END`,
+			expectedNames: []string{"accounts", "members"},
+		},
+		"return expression": {
+			sql:           `CREATE FUNCTION f() RETURNS bigint RETURN (SELECT count(*) FROM accounts)`,
+			expectedNames: []string{"accounts"},
+		},
+		"plpgsql statements and expressions": {
+			sql: `CREATE FUNCTION f() RETURNS int LANGUAGE plpgsql AS $$
+DECLARE n int;
+BEGIN
+  n := (SELECT count(*) FROM accounts);
+  IF EXISTS (SELECT 1 FROM members) THEN
+    UPDATE orders SET total = 0;
+  END IF;
+  RETURN n;
+END $$`,
+			expectedNames: []string{"accounts", "members", "orders"},
+		},
+		"plpgsql dynamic sql": {
+			sql:           `CREATE FUNCTION f() RETURNS void LANGUAGE plpgsql AS $$ BEGIN EXECUTE 'DELETE FROM accounts'; END $$`,
+			expectedNames: []string{},
+		},
+		"c function": {
+			sql:           `CREATE FUNCTION f() RETURNS int LANGUAGE c AS 'module', 'f'`,
+			expectedNames: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse(tc.sql)
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, query := range functionBody(tree.Stmts[0].Stmt.GetCreateFunctionStmt()) {
+				for _, relation := range collectRangeVars(query) {
+					names = append(names, QualifiedName{Schema: relation.GetSchemaname(), Name: relation.GetRelname()}.String())
+				}
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
+func TestFilterColumns(t *testing.T) {
+	testCases := map[string]struct {
+		sql      string
+		expected []string
+	}{
+		"select without where": {sql: `SELECT tenant_id FROM accounts`, expected: []string{}},
+		"select with where":    {sql: `SELECT * FROM accounts a WHERE a.tenant_id = 1 AND id > 0`, expected: []string{"tenant_id", "id"}},
+		"update with where":    {sql: `UPDATE accounts SET name = 'x' WHERE id = 1`, expected: []string{"id"}},
+		"filtered subquery":    {sql: `DELETE FROM accounts WHERE id IN (SELECT id FROM members WHERE tenant_id = 1)`, expected: []string{"id", "tenant_id"}},
+		"insert columns":       {sql: `INSERT INTO accounts (id, tenant_id) VALUES (1, 2)`, expected: []string{"id", "tenant_id"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse(tc.sql)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, filterColumns(tree.Stmts[0].Stmt))
+		})
+	}
+}
+
+func TestHasRowFilter(t *testing.T) {
+	testCases := map[string]struct {
+		sql      string
+		expected bool
+	}{
+		"select without where":  {sql: `SELECT * FROM accounts`, expected: false},
+		"select with where":     {sql: `SELECT * FROM accounts WHERE tenant_id = 1`, expected: true},
+		"update without where":  {sql: `UPDATE accounts SET name = 'x'`, expected: false},
+		"delete with where":     {sql: `DELETE FROM accounts WHERE id = 1`, expected: true},
+		"filtered subquery":     {sql: `SELECT (SELECT count(*) FROM accounts WHERE tenant_id = 1)`, expected: true},
+		"insert without select": {sql: `INSERT INTO accounts VALUES (1)`, expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := pg_query.Parse(tc.sql)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, hasRowFilter(tree.Stmts[0].Stmt))
+		})
+	}
+}
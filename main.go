@@ -9,7 +9,7 @@ import (
 
 func main() {
 	// コマンドライン引数の解析
-	excludedTables, useStdin := ParseFlags()
+	options, useStdin := ParseFlags()
 
 	var sources []SourceFile
 
@@ -47,11 +47,8 @@ func main() {
 	}
 
 	// リンターの実行
-	options := LinterOptions{
-		Sources:        sources,
-		Writer:         os.Stdout,
-		ExcludedTables: excludedTables,
-	}
+	options.Sources = sources
+	options.Writer = os.Stdout
 
 	if err := RunLinter(options); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -66,10 +63,13 @@ func RunLinter(options LinterOptions) error {
 		return fmt.Errorf("no input sources specified")
 	}
 
-	// すべてのソースからテーブル定義、RLS有効化、ポリシーを収集
-	var allTables []TableDefinition
-	var allRLSEnables []RLSEnableStatement
-	var allPolicies []PolicyStatement
+	// ルールで使用する式の確認
+	if _, err := parseExpr(options.Rules.TenantExpression); err != nil {
+		return fmt.Errorf("invalid tenant expression: %w", err)
+	}
+
+	// すべてのソースからステートメントをファイル順・ソース順に収集
+	var allStatements []Statement
 
 	for _, source := range options.Sources {
 		// SQLの読み込み
@@ -79,19 +79,19 @@ func RunLinter(options LinterOptions) error {
 		}
 
 		// SQLの解析
-		tables, rlsEnables, policies, err := ParseSQL(source.Filename, string(sqlBytes))
+		statements, err := ParseStatements(source.Filename, string(sqlBytes), ParseOptions{
+			SearchPath: options.SearchPath,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to parse SQL: %s: %w", source.Filename, err)
 		}
 
 		// 結果を統合
-		allTables = append(allTables, tables...)
-		allRLSEnables = append(allRLSEnables, rlsEnables...)
-		allPolicies = append(allPolicies, policies...)
+		allStatements = append(allStatements, statements...)
 	}
 
-	// RLS設定の検証
-	results := ValidateRLS(allTables, allRLSEnables, allPolicies, options.ExcludedTables)
+	// ステートメントを再生してRLS設定を検証
+	results := Validate(allStatements, options.ExcludedTables, options.Rules)
 
 	// 結果の出力
 	return OutputResults(results, options.Writer)
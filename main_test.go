@@ -37,7 +37,7 @@ func TestValidateRLSMissingEnable(t *testing.T) {
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 	assert.Len(t, result, 1)
-	assert.Equal(t, "accounts", result[0].TableName)
+	assert.Equal(t, "public.accounts", result[0].TableName)
 	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
 }
 
@@ -50,7 +50,7 @@ func TestValidateRLSMissingPolicy(t *testing.T) {
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 	assert.Len(t, result, 1)
-	assert.Equal(t, "accounts", result[0].TableName)
+	assert.Equal(t, "public.accounts", result[0].TableName)
 	assert.Equal(t, "rls-no-policy", result[0].RuleID)
 }
 
@@ -85,7 +85,7 @@ func TestValidateRLSMultipleTables(t *testing.T) {
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 	assert.Len(t, result, 1)
-	assert.Equal(t, "users", result[0].TableName)
+	assert.Equal(t, "public.users", result[0].TableName)
 }
 
 // TestRunLinterWithSingleSource は単一ソースに対するリンター実行をテストする
@@ -112,7 +112,7 @@ func TestRunLinterWithSingleSource(t *testing.T) {
 			expectOutput:   `"rule_id": "rls-no-policy"`,
 		},
 		"correct RLS": {
-			input:          `CREATE TABLE accounts (id int); ALTER TABLE accounts ENABLE ROW LEVEL SECURITY; CREATE POLICY p ON accounts USING (true);`,
+			input:          `CREATE TABLE accounts (id int); ALTER TABLE accounts ENABLE ROW LEVEL SECURITY; CREATE POLICY p ON accounts USING (current_user = 'app');`,
 			filename:       "test.sql",
 			excludedTables: []string{},
 			expectError:    false,
@@ -135,7 +135,7 @@ func TestRunLinterWithSingleSource(t *testing.T) {
 			filename:       "test.sql",
 			excludedTables: []string{},
 			expectError:    true,
-			expectOutput:   `"table_name": "users"`,
+			expectOutput:   `"table_name": "public.users"`,
 		},
 		"stdin file": {
 			input:          `CREATE TABLE accounts (id int, manager text);`,
@@ -222,8 +222,10 @@ CREATE POLICY product_policy ON products USING (true);`
 
 	// 検証
 	assert.Error(t, err)
-	assert.Contains(t, outBuf.String(), `"table_name": "users"`)
-	assert.Contains(t, outBuf.String(), `"table_name": "orders"`)
+	assert.Contains(t, outBuf.String(), `"table_name": "public.users"`)
+	assert.Contains(t, outBuf.String(), `"table_name": "public.orders"`)
+	assert.Contains(t, outBuf.String(), `"rule_id": "rls-policy-always-true"`)
+	assert.Contains(t, outBuf.String(), `"severity": "warning"`)
 	assert.Contains(t, outBuf.String(), "virtual_file1.sql")
 	assert.Contains(t, outBuf.String(), "virtual_file2.sql")
 }
@@ -252,8 +254,8 @@ CREATE TABLE users (id int, name text);`
 
 	// 検証
 	assert.Error(t, err) // RLS設定の不足があるためエラーが発生する
-	assert.Contains(t, outBuf.String(), `"table_name": "accounts"`)
-	assert.NotContains(t, outBuf.String(), `"table_name": "users"`)
+	assert.Contains(t, outBuf.String(), `"table_name": "public.accounts"`)
+	assert.NotContains(t, outBuf.String(), `"table_name": "public.users"`)
 }
 
 // TestMultiplePolicies は1つのテーブルに対して複数のポリシーがある場合をテストする
@@ -262,7 +264,7 @@ func TestMultiplePolicies(t *testing.T) {
 	CREATE TABLE accounts (id int, manager text, department text);
 	ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
 	CREATE POLICY manager_policy ON accounts USING (manager = current_user);
-	CREATE POLICY department_policy ON accounts USING (department = current_setting('app.department'));
+	CREATE POLICY department_policy ON accounts USING (department = current_setting('app.department', true));
 	`
 	tables, rlsEnables, policies, err := ParseSQL("test.sql", input)
 	assert.NoError(t, err)
@@ -331,9 +333,121 @@ func TestMultiByteCharacters(t *testing.T) {
 			results := ValidateRLS(tables, rlsEnables, policies, []string{})
 			assert.Len(t, results, 1, "RLS設定の不足が検出されるべきです")
 			if len(results) > 0 {
-				assert.Equal(t, tc.expectTableName, results[0].TableName, "検証結果のテーブル名が一致しません")
+				assert.Equal(t, "public."+tc.expectTableName, results[0].TableName, "検証結果のテーブル名が一致しません")
 				assert.Equal(t, "rls-not-enabled", results[0].RuleID, "RLSが有効化されていないことが検出されるべきです")
 			}
 		})
 	}
 }
+
+// TestRunLinterWithSearchPath はsearch_pathを指定したリンター実行をテストする
+func TestRunLinterWithSearchPath(t *testing.T) {
+	sqlContent := `CREATE TABLE accounts (id int);
+ALTER TABLE auth.accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON auth.accounts USING (current_user = 'app');`
+
+	outBuf := &bytes.Buffer{}
+	options := LinterOptions{
+		Sources: []SourceFile{
+			{
+				Reader:   strings.NewReader(sqlContent),
+				Filename: "test.sql",
+			},
+		},
+		Writer:     outBuf,
+		SearchPath: []string{"app", "public"},
+	}
+	err := RunLinter(options)
+
+	// auth.accountsへのRLS有効化はapp.accountsを満たさない
+	assert.Error(t, err)
+	assert.Contains(t, outBuf.String(), `"table_name": "app.accounts"`)
+	assert.Contains(t, outBuf.String(), `"rule_id": "rls-not-enabled"`)
+}
+
+// TestRunLinterWithSetSearchPath はファイルごとにsearch_pathが追跡されることをテストする
+func TestRunLinterWithSetSearchPath(t *testing.T) {
+	file1Content := `SET search_path TO tenant, public;
+CREATE TABLE accounts (id int);
+CREATE TABLE public.accounts (id int);
+ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON public.accounts USING (current_user = 'app');`
+
+	// search_pathはファイルごとにリセットされる
+	file2Content := `CREATE SCHEMA billing CREATE TABLE invoices (id int);
+ALTER TABLE billing.invoices ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON billing.invoices USING (current_user = 'app');
+CREATE TABLE orders (id int);`
+
+	outBuf := &bytes.Buffer{}
+	options := LinterOptions{
+		Sources: []SourceFile{
+			{
+				Reader:   strings.NewReader(file1Content),
+				Filename: "file1.sql",
+			},
+			{
+				Reader:   strings.NewReader(file2Content),
+				Filename: "file2.sql",
+			},
+		},
+		Writer: outBuf,
+	}
+	err := RunLinter(options)
+
+	assert.Error(t, err)
+	assert.Contains(t, outBuf.String(), `"table_name": "tenant.accounts"`)
+	assert.Contains(t, outBuf.String(), `"table_name": "public.orders"`)
+	assert.NotContains(t, outBuf.String(), `"table_name": "public.accounts"`)
+	assert.NotContains(t, outBuf.String(), `"table_name": "billing.invoices"`)
+}
+
+// TestRunLinterLocation は出力される位置情報が行・列番号になっていることをテストする
+func TestRunLinterLocation(t *testing.T) {
+	sqlContent := `CREATE TABLE accounts (id int);
+
+-- ユーザーテーブル
+CREATE TABLE users (
+  id int
+);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');`
+
+	outBuf := &bytes.Buffer{}
+	options := LinterOptions{
+		Sources: []SourceFile{
+			{
+				Reader:   strings.NewReader(sqlContent),
+				Filename: "test.sql",
+			},
+		},
+		Writer: outBuf,
+	}
+	err := RunLinter(options)
+
+	assert.Error(t, err)
+	assert.Contains(t, outBuf.String(), `"line": 4,`)
+	assert.Contains(t, outBuf.String(), `"column": 14,`)
+	assert.Contains(t, outBuf.String(), `"end_line": 6,`)
+	assert.Contains(t, outBuf.String(), `"end_column": 2`)
+}
+
+// TestRunLinterWithInvalidTenantExpression はテナントの列と比較する式が解析できない場合をテストする
+func TestRunLinterWithInvalidTenantExpression(t *testing.T) {
+	outBuf := &bytes.Buffer{}
+	options := LinterOptions{
+		Sources: []SourceFile{
+			{
+				Reader:   strings.NewReader(`CREATE TABLE accounts (id int);`),
+				Filename: "test.sql",
+			},
+		},
+		Writer: outBuf,
+		Rules:  RuleOptions{TenantColumn: "tenant_id", TenantExpression: "current_setting("},
+	}
+	err := RunLinter(options)
+
+	// 検証前にエラーになり、結果は出力されない
+	assert.ErrorContains(t, err, "invalid tenant expression")
+	assert.Empty(t, outBuf.String())
+}
@@ -1,115 +1,868 @@
 package main
 
 import (
+	"strconv"
+	"strings"
+
 	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
 )
 
-// ParseSQL はSQLを解析してステートメントを抽出する
+// DefaultSearchPath は未修飾のテーブル名を解決するデフォルトのsearch_path
+var DefaultSearchPath = []string{"public"}
+
+// ParseSQL はデフォルトのsearch_pathでSQLを解析してステートメントを抽出する
 func ParseSQL(filename string, sql string) ([]TableDefinition, []RLSEnableStatement, []PolicyStatement, error) {
+	return ParseSQLWithOptions(filename, sql, ParseOptions{})
+}
+
+// ParseSQLWithOptions はオプションを指定してSQLを解析しステートメントを抽出する
+func ParseSQLWithOptions(filename string, sql string, options ParseOptions) ([]TableDefinition, []RLSEnableStatement, []PolicyStatement, error) {
+	statements, err := ParseStatements(filename, sql, options)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tables, rlsEnables, policies := splitStatements(statements)
+	return tables, rlsEnables, policies, nil
+}
+
+// ParseStatements はSQLを解析して検証対象のステートメントをソース順に抽出する
+func ParseStatements(filename string, sql string, options ParseOptions) ([]Statement, error) {
 	// SQLの解析
 	tree, err := pg_query.Parse(sql)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
 	// 各種ステートメントの抽出
-	tables := extractTableDefinitions(filename, tree)
-	rlsEnables := extractRLSEnableStatements(filename, tree)
-	policies := extractPolicyStatements(filename, tree)
+	parser, err := extractStatements(filename, sql, tree, options.SearchPath)
+	if err != nil {
+		return nil, err
+	}
 
-	return tables, rlsEnables, policies, nil
+	return parser.statements, nil
 }
 
-// extractTableDefinitions はCREATE TABLE文を抽出する
-func extractTableDefinitions(filename string, tree *pg_query.ParseResult) []TableDefinition {
+// splitStatements はステートメントを種類ごとに分類する
+func splitStatements(statements []Statement) ([]TableDefinition, []RLSEnableStatement, []PolicyStatement) {
 	tables := make([]TableDefinition, 0)
+	rlsEnables := make([]RLSEnableStatement, 0)
+	policies := make([]PolicyStatement, 0)
+
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *TableDefinition:
+			tables = append(tables, *stmt)
+		case *RLSEnableStatement:
+			rlsEnables = append(rlsEnables, *stmt)
+		case *PolicyStatement:
+			policies = append(policies, *stmt)
+		}
+	}
+
+	return tables, rlsEnables, policies
+}
+
+// statementParser はファイル単位の解析状態と抽出結果を保持する構造体
+type statementParser struct {
+	filename          string
+	index             *sourceIndex
+	defaultSearchPath []string
+	searchPath        []string // SET search_path により変化する現在のsearch_path
+
+	statements []Statement // 抽出したステートメント（ソース順）
+}
+
+// extractStatements は解析済みのSQLからステートメントを先頭から順に抽出する
+func extractStatements(filename string, sql string, tree *pg_query.ParseResult, searchPath []string) (*statementParser, error) {
+	if len(searchPath) == 0 {
+		searchPath = DefaultSearchPath
+	}
+
+	// 位置情報の変換用インデックスの作成
+	index, err := newSourceIndex(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &statementParser{
+		filename:          filename,
+		index:             index,
+		defaultSearchPath: searchPath,
+		searchPath:        searchPath,
+		statements:        make([]Statement, 0),
+	}
 
 	for _, stmt := range tree.Stmts {
-		if res := stmt.Stmt.GetCreateStmt(); res != nil {
-			tableName := res.GetRelation().GetRelname()
-
-			// 位置情報の取得
-			location := SQLStatement{
-				Filename: filename,
-				Line:     int(stmt.StmtLocation), // 実際の位置情報を使用
-				Column:   1,
+		// 位置情報の取得
+		location := index.statementLocation(filename, int(stmt.StmtLocation), int(stmt.StmtLen))
+		parser.parseStatement(stmt.Stmt, location)
+	}
+
+	return parser, nil
+}
+
+// parseStatement は1つのステートメントを解析して抽出結果に追加する
+func (p *statementParser) parseStatement(node *pg_query.Node, location SQLStatement) {
+	switch {
+	case node.GetCreateStmt() != nil:
+		p.parseCreateStmt(node.GetCreateStmt(), location)
+	case node.GetCreateTableAsStmt() != nil:
+		p.parseCreateTableAsStmt(node.GetCreateTableAsStmt(), location)
+	case node.GetSelectStmt() != nil:
+		p.parseSelectStmt(node.GetSelectStmt(), location)
+	case node.GetViewStmt() != nil:
+		p.parseViewStmt(node.GetViewStmt(), location)
+	case node.GetAlterTableStmt() != nil:
+		p.parseAlterTableStmt(node.GetAlterTableStmt(), location)
+	case node.GetCreatePolicyStmt() != nil:
+		p.parseCreatePolicyStmt(node.GetCreatePolicyStmt(), location)
+	case node.GetDropStmt() != nil:
+		p.parseDropStmt(node.GetDropStmt(), location)
+	case node.GetAlterPolicyStmt() != nil:
+		p.parseAlterPolicyStmt(node.GetAlterPolicyStmt(), location)
+	case node.GetRenameStmt().GetRenameType() == pg_query.ObjectType_OBJECT_ROLE:
+		p.parseRoleStmt(node, location)
+	case node.GetRenameStmt() != nil:
+		p.parseRenameStmt(node.GetRenameStmt(), location)
+	case node.GetAlterObjectSchemaStmt() != nil:
+		p.parseAlterObjectSchemaStmt(node.GetAlterObjectSchemaStmt(), location)
+	case node.GetVariableSetStmt() != nil:
+		p.parseVariableSetStmt(node.GetVariableSetStmt(), node, location)
+	case node.GetGrantStmt() != nil:
+		p.parseGrantStmt(node.GetGrantStmt(), location)
+	case node.GetAlterDefaultPrivilegesStmt() != nil:
+		p.parseAlterDefaultPrivilegesStmt(node.GetAlterDefaultPrivilegesStmt(), location)
+	case node.GetCreateRoleStmt() != nil, node.GetAlterRoleStmt() != nil, node.GetDropRoleStmt() != nil:
+		p.parseRoleStmt(node, location)
+	case node.GetCreateFunctionStmt() != nil:
+		p.parseCreateFunctionStmt(node.GetCreateFunctionStmt(), location)
+	case node.GetCreateSchemaStmt() != nil:
+		p.parseCreateSchemaStmt(node.GetCreateSchemaStmt(), location)
+	}
+}
+
+// parseCreateStmt はCREATE TABLE文を抽出する
+func (p *statementParser) parseCreateStmt(stmt *pg_query.CreateStmt, location SQLStatement) {
+	schemaName, _ := resolveSchema(stmt.GetRelation(), p.searchPath)
+
+	// CREATE TABLE ... PARTITION OF の場合は親テーブルを記録する
+	var partitionOf *TableReference
+	if stmt.GetPartbound() != nil && len(stmt.GetInhRelations()) > 0 {
+		partitionOf = p.tableReference(stmt.GetInhRelations()[0].GetRangeVar())
+	}
+
+	p.statements = append(p.statements, &TableDefinition{
+		SQLStatement: p.index.withName(location, stmt.GetRelation()),
+		TableName:    stmt.GetRelation().GetRelname(),
+		SchemaName:   schemaName,
+		PartitionOf:  partitionOf,
+		IfNotExists:  stmt.GetIfNotExists(),
+		Columns:      tableColumns(stmt),
+		ForeignKeys:  p.foreignKeys(stmt),
+		Statement:    stmt,
+	})
+}
+
+// foreignKeys はCREATE TABLEで定義された外部キー制約（列制約を含む）を抽出する
+func (p *statementParser) foreignKeys(stmt *pg_query.CreateStmt) []ForeignKey {
+	foreignKeys := make([]ForeignKey, 0)
+	for _, elt := range stmt.GetTableElts() {
+		if column := elt.GetColumnDef(); column != nil {
+			for _, constraint := range column.GetConstraints() {
+				if foreignKey := p.foreignKey(stmt.GetRelation().GetRelname(), constraint.GetConstraint(), []string{column.GetColname()}); foreignKey != nil {
+					foreignKeys = append(foreignKeys, *foreignKey)
+				}
 			}
+		} else if foreignKey := p.foreignKey(stmt.GetRelation().GetRelname(), elt.GetConstraint(), nil); foreignKey != nil {
+			foreignKeys = append(foreignKeys, *foreignKey)
+		}
+	}
+	return foreignKeys
+}
 
-			tables = append(tables, TableDefinition{
-				SQLStatement: location,
-				TableName:    tableName,
-				Statement:    res,
-			})
+// foreignKey は制約が外部キー制約の場合に参照先を作成する（外部キー制約でない場合はnil）
+// 列制約の場合はcolumnsに列名を指定する
+func (p *statementParser) foreignKey(tableName string, constraint *pg_query.Constraint, columns []string) *ForeignKey {
+	if constraint.GetContype() != pg_query.ConstrType_CONSTR_FOREIGN {
+		return nil
+	}
+
+	// 制約名の省略時はPostgreSQLと同じく "テーブル名_列名_fkey" とする
+	name := constraint.GetConname()
+	if name == "" {
+		if columns == nil {
+			columns = nameList(constraint.GetFkAttrs())
+		}
+		name = tableName + "_" + strings.Join(columns, "_") + "_fkey"
+	}
+	return &ForeignKey{
+		ConstraintName: name,
+		References:     *p.tableReference(constraint.GetPktable()),
+	}
+}
+
+// tableColumns はCREATE TABLEで定義された列名を返す
+// パーティションの場合は親テーブルの列に追加される列のみを返し、
+// LIKE、INHERITS、OF 型名で列を引き継ぐ場合は列を特定できないためnilを返す
+func tableColumns(stmt *pg_query.CreateStmt) []string {
+	if stmt.GetOfTypename() != nil || (stmt.GetPartbound() == nil && len(stmt.GetInhRelations()) > 0) {
+		return nil
+	}
+
+	columns := make([]string, 0, len(stmt.GetTableElts()))
+	for _, elt := range stmt.GetTableElts() {
+		switch {
+		case elt.GetColumnDef() != nil:
+			columns = append(columns, elt.GetColumnDef().GetColname())
+		case elt.GetTableLikeClause() != nil:
+			return nil
 		}
 	}
+	return columns
+}
+
+// parseCreateTableAsStmt はCREATE TABLE AS文とCREATE MATERIALIZED VIEW文を抽出する
+func (p *statementParser) parseCreateTableAsStmt(stmt *pg_query.CreateTableAsStmt, location SQLStatement) {
+	kind := TableKindTableAs
+	switch stmt.GetObjtype() {
+	case pg_query.ObjectType_OBJECT_TABLE:
+	case pg_query.ObjectType_OBJECT_MATVIEW:
+		kind = TableKindMaterializedView
+	default:
+		return
+	}
 
-	return tables
+	definition := p.derivedTable(kind, stmt.GetInto().GetRel(), stmt.GetQuery(), location)
+	definition.IfNotExists = stmt.GetIfNotExists()
+	definition.WithData = !stmt.GetInto().GetSkipData()
+	definition.Options = relOptions(stmt.GetInto().GetOptions())
+	p.statements = append(p.statements, definition)
 }
 
-// extractRLSEnableStatements はALTER TABLE ... ENABLE ROW LEVEL SECURITY文を抽出する
-func extractRLSEnableStatements(filename string, tree *pg_query.ParseResult) []RLSEnableStatement {
-	rlsEnables := make([]RLSEnableStatement, 0)
+// parseSelectStmt はSELECT INTO文とset_configによるセッションの設定の変更を抽出する
+func (p *statementParser) parseSelectStmt(stmt *pg_query.SelectStmt, location SQLStatement) {
+	query := &pg_query.Node{Node: &pg_query.Node_SelectStmt{SelectStmt: stmt}}
+	p.parseSetConfigCalls(query, location)
+	if stmt.GetIntoClause() == nil {
+		return
+	}
 
-	for _, stmt := range tree.Stmts {
-		if res := stmt.Stmt.GetAlterTableStmt(); res != nil {
-			tableName := res.GetRelation().GetRelname()
-
-			// RLS有効化のステートメントかチェック
-			isRLSEnable := false
-			for _, cmd := range res.Cmds {
-				if cmd.GetAlterTableCmd() != nil {
-					subtype := cmd.GetAlterTableCmd().Subtype
-					if subtype == pg_query.AlterTableType_AT_EnableRowSecurity {
-						isRLSEnable = true
-						break
-					}
-				}
+	definition := p.derivedTable(TableKindSelectInto, stmt.GetIntoClause().GetRel(), query, location)
+	definition.WithData = true
+	p.statements = append(p.statements, definition)
+}
+
+// parseViewStmt はCREATE VIEW文を抽出する
+func (p *statementParser) parseViewStmt(stmt *pg_query.ViewStmt, location SQLStatement) {
+	definition := p.derivedTable(TableKindView, stmt.GetView(), stmt.GetQuery(), location)
+	definition.Replace = stmt.GetReplace()
+	definition.Options = relOptions(stmt.GetOptions())
+	p.statements = append(p.statements, definition)
+}
+
+// derivedTable はクエリの結果から作成されるテーブルまたはビューの定義を作成する
+func (p *statementParser) derivedTable(kind TableKind, relation *pg_query.RangeVar, query *pg_query.Node, location SQLStatement) *TableDefinition {
+	schemaName, _ := resolveSchema(relation, p.searchPath)
+
+	return &TableDefinition{
+		SQLStatement: p.index.withName(location, relation),
+		Kind:         kind,
+		TableName:    relation.GetRelname(),
+		SchemaName:   schemaName,
+		Query:        query,
+		SourceTables: p.tableReferences(query),
+	}
+}
+
+// rlsActions はRLS設定を変更するALTER TABLEのサブコマンドと変更内容の対応
+var rlsActions = map[pg_query.AlterTableType]RLSAction{
+	pg_query.AlterTableType_AT_EnableRowSecurity:  RLSEnable,
+	pg_query.AlterTableType_AT_DisableRowSecurity: RLSDisable,
+	pg_query.AlterTableType_AT_ForceRowSecurity:   RLSForce,
+	pg_query.AlterTableType_AT_NoForceRowSecurity: RLSNoForce,
+}
+
+// parseAlterTableStmt はALTER TABLE ... ENABLE/DISABLE/FORCE/NO FORCE ROW LEVEL SECURITY文と
+// ALTER TABLE / ALTER VIEW ... SET (...) / RESET (...) 文を抽出する
+func (p *statementParser) parseAlterTableStmt(stmt *pg_query.AlterTableStmt, location SQLStatement) {
+	// RLS設定、パラメータ、列、外部キー制約を変更するサブコマンドを記述順に抽出
+	for _, cmd := range stmt.Cmds {
+		if cmd.GetAlterTableCmd() == nil {
+			continue
+		}
+
+		subtype := cmd.GetAlterTableCmd().Subtype
+		if subtype == pg_query.AlterTableType_AT_AddColumn || subtype == pg_query.AlterTableType_AT_DropColumn {
+			columnName := cmd.GetAlterTableCmd().GetName()
+			if subtype == pg_query.AlterTableType_AT_AddColumn {
+				columnName = cmd.GetAlterTableCmd().GetDef().GetColumnDef().GetColname()
 			}
+			p.statements = append(p.statements, &ColumnStatement{
+				SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+				TableReference: *p.tableReference(stmt.GetRelation()),
+				ColumnName:     columnName,
+				Drop:           subtype == pg_query.AlterTableType_AT_DropColumn,
+				Statement:      stmt,
+			})
+			continue
+		}
+		if subtype == pg_query.AlterTableType_AT_SetRelOptions || subtype == pg_query.AlterTableType_AT_ResetRelOptions {
+			p.statements = append(p.statements, &RelOptionsStatement{
+				SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+				TableReference: *p.tableReference(stmt.GetRelation()),
+				Options:        relOptions(cmd.GetAlterTableCmd().GetDef().GetList().GetItems()),
+				Reset:          subtype == pg_query.AlterTableType_AT_ResetRelOptions,
+				Statement:      stmt,
+			})
+			continue
+		}
 
-			if isRLSEnable {
-				// 位置情報の取得
-				location := SQLStatement{
-					Filename: filename,
-					Line:     int(stmt.StmtLocation),
-					Column:   1,
+		if subtype == pg_query.AlterTableType_AT_AddConstraint || subtype == pg_query.AlterTableType_AT_DropConstraint {
+			foreignKey := &ForeignKey{ConstraintName: cmd.GetAlterTableCmd().GetName()}
+			if subtype == pg_query.AlterTableType_AT_AddConstraint {
+				if foreignKey = p.foreignKey(stmt.GetRelation().GetRelname(), cmd.GetAlterTableCmd().GetDef().GetConstraint(), nil); foreignKey == nil {
+					continue
 				}
-
-				rlsEnables = append(rlsEnables, RLSEnableStatement{
-					SQLStatement: location,
-					TableName:    tableName,
-					Statement:    res,
-				})
 			}
+			p.statements = append(p.statements, &ForeignKeyStatement{
+				SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+				TableReference: *p.tableReference(stmt.GetRelation()),
+				ForeignKey:     *foreignKey,
+				Drop:           subtype == pg_query.AlterTableType_AT_DropConstraint,
+				Statement:      stmt,
+			})
+			continue
 		}
+
+		action, ok := rlsActions[subtype]
+		if !ok {
+			continue
+		}
+
+		p.statements = append(p.statements, &RLSEnableStatement{
+			SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+			Action:         action,
+			TableReference: *p.tableReference(stmt.GetRelation()),
+			MissingOk:      stmt.GetMissingOk(),
+			Statement:      stmt,
+		})
 	}
+}
 
-	return rlsEnables
+// parseCreatePolicyStmt はCREATE POLICY文を抽出する
+func (p *statementParser) parseCreatePolicyStmt(stmt *pg_query.CreatePolicyStmt, location SQLStatement) {
+	p.statements = append(p.statements, &PolicyStatement{
+		SQLStatement:    p.index.withName(location, stmt.GetTable()),
+		TableReference:  *p.tableReference(stmt.GetTable()),
+		PolicyName:      stmt.GetPolicyName(),
+		Command:         stmt.GetCmdName(),
+		Restrictive:     !stmt.GetPermissive(),
+		Roles:           roleNames(stmt.GetRoles()),
+		UsingTables:     p.tableReferences(stmt.GetQual()),
+		WithCheckTables: p.tableReferences(stmt.GetWithCheck()),
+		Statement:       stmt,
+	})
 }
 
-// extractPolicyStatements はCREATE POLICY文を抽出する
-func extractPolicyStatements(filename string, tree *pg_query.ParseResult) []PolicyStatement {
-	policies := make([]PolicyStatement, 0)
+// parseDropStmt はDROP TABLE文（DROP VIEW / DROP MATERIALIZED VIEWを含む）とDROP POLICY文を抽出する
+func (p *statementParser) parseDropStmt(stmt *pg_query.DropStmt, location SQLStatement) {
+	for _, object := range stmt.GetObjects() {
+		names := nameList(object.GetList().GetItems())
+		if len(names) == 0 {
+			continue
+		}
 
-	for _, stmt := range tree.Stmts {
-		if res := stmt.Stmt.GetCreatePolicyStmt(); res != nil {
-			tableName := res.GetTable().GetRelname()
-			policyName := res.GetPolicyName()
-
-			// 位置情報の取得
-			location := SQLStatement{
-				Filename: filename,
-				Line:     int(stmt.StmtLocation),
-				Column:   1,
+		switch stmt.GetRemoveType() {
+		case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			p.statements = append(p.statements, &DropTableStatement{
+				SQLStatement:   location,
+				TableReference: p.qualifiedReference(names),
+				Statement:      stmt,
+			})
+		case pg_query.ObjectType_OBJECT_POLICY:
+			// ポリシーは [スキーマ, テーブル, ポリシー] の形式で指定される
+			if len(names) < 2 {
+				continue
 			}
+			p.statements = append(p.statements, &DropPolicyStatement{
+				SQLStatement:   location,
+				TableReference: p.qualifiedReference(names[:len(names)-1]),
+				PolicyName:     names[len(names)-1],
+				Statement:      stmt,
+			})
+		}
+	}
+}
 
-			policies = append(policies, PolicyStatement{
+// parseAlterPolicyStmt はALTER POLICY文を抽出する
+func (p *statementParser) parseAlterPolicyStmt(stmt *pg_query.AlterPolicyStmt, location SQLStatement) {
+	p.statements = append(p.statements, &AlterPolicyStatement{
+		SQLStatement:    p.index.withName(location, stmt.GetTable()),
+		TableReference:  *p.tableReference(stmt.GetTable()),
+		PolicyName:      stmt.GetPolicyName(),
+		UsingTables:     p.tableReferences(stmt.GetQual()),
+		WithCheckTables: p.tableReferences(stmt.GetWithCheck()),
+		Statement:       stmt,
+	})
+}
+
+// parseRenameStmt はテーブル（ビューとマテリアライズドビューを含む）とポリシーの名前の変更を抽出する
+func (p *statementParser) parseRenameStmt(stmt *pg_query.RenameStmt, location SQLStatement) {
+	switch stmt.GetRenameType() {
+	case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW, pg_query.ObjectType_OBJECT_POLICY:
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		if stmt.GetRelationType() != pg_query.ObjectType_OBJECT_TABLE {
+			return
+		}
+	default:
+		return
+	}
+
+	p.statements = append(p.statements, &RenameStatement{
+		SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+		ObjectType:     stmt.GetRenameType(),
+		TableReference: *p.tableReference(stmt.GetRelation()),
+		OldName:        stmt.GetSubname(),
+		NewName:        stmt.GetNewname(),
+		Statement:      stmt,
+	})
+}
+
+// parseAlterObjectSchemaStmt はALTER TABLE / ALTER VIEW / ALTER MATERIALIZED VIEW ... SET SCHEMA 文を抽出する
+func (p *statementParser) parseAlterObjectSchemaStmt(stmt *pg_query.AlterObjectSchemaStmt, location SQLStatement) {
+	switch stmt.GetObjectType() {
+	case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+	default:
+		return
+	}
+
+	p.statements = append(p.statements, &SetSchemaStatement{
+		SQLStatement:   p.index.withName(location, stmt.GetRelation()),
+		TableReference: *p.tableReference(stmt.GetRelation()),
+		NewSchemaName:  stmt.GetNewschema(),
+		Statement:      stmt,
+	})
+}
+
+// parseGrantStmt はテーブルに対するGRANT / REVOKE文を抽出する
+func (p *statementParser) parseGrantStmt(stmt *pg_query.GrantStmt, location SQLStatement) {
+	if stmt.GetObjtype() != pg_query.ObjectType_OBJECT_TABLE {
+		return
+	}
+
+	grant := grantStatement(stmt, location)
+	switch stmt.GetTargtype() {
+	case pg_query.GrantTargetType_ACL_TARGET_OBJECT:
+		for _, object := range stmt.GetObjects() {
+			grant.Tables = append(grant.Tables, *p.tableReference(object.GetRangeVar()))
+		}
+	case pg_query.GrantTargetType_ACL_TARGET_ALL_IN_SCHEMA:
+		grant.Target = GrantTargetAllTablesInSchema
+		grant.Schemas = nameList(stmt.GetObjects())
+	default:
+		return
+	}
+	p.statements = append(p.statements, grant)
+}
+
+// parseAlterDefaultPrivilegesStmt はテーブルに対するALTER DEFAULT PRIVILEGES文を抽出する
+// FOR ROLE の指定は静的解析ではテーブルの作成者を特定できないため考慮しない
+func (p *statementParser) parseAlterDefaultPrivilegesStmt(stmt *pg_query.AlterDefaultPrivilegesStmt, location SQLStatement) {
+	if stmt.GetAction().GetObjtype() != pg_query.ObjectType_OBJECT_TABLE {
+		return
+	}
+
+	grant := grantStatement(stmt.GetAction(), location)
+	grant.Target = GrantTargetDefaults
+	for _, option := range stmt.GetOptions() {
+		if option.GetDefElem().GetDefname() == "schemas" {
+			grant.Schemas = nameList(option.GetDefElem().GetArg().GetList().GetItems())
+		}
+	}
+	p.statements = append(p.statements, grant)
+}
+
+// grantStatement はGRANT / REVOKEの権限と対象のロールを抽出する
+func grantStatement(stmt *pg_query.GrantStmt, location SQLStatement) *GrantStatement {
+	privileges := make([]string, 0, len(stmt.GetPrivileges()))
+	columnPrivileges := make(map[string][]string)
+	for _, privilege := range stmt.GetPrivileges() {
+		name := privilege.GetAccessPriv().GetPrivName()
+		if name == "" {
+			// ALL (列, ...) は権限名が省略される
+			name = "all"
+		}
+		if columns := nameList(privilege.GetAccessPriv().GetCols()); len(columns) > 0 {
+			columnPrivileges[name] = append(columnPrivileges[name], columns...)
+			continue
+		}
+		privileges = append(privileges, name)
+	}
+	if len(stmt.GetPrivileges()) == 0 {
+		// 権限のリストが空の場合はALL PRIVILEGES
+		privileges = append(privileges, "all")
+	}
+
+	return &GrantStatement{
+		SQLStatement:     location,
+		IsGrant:          stmt.GetIsGrant(),
+		Privileges:       privileges,
+		ColumnPrivileges: columnPrivileges,
+		Grantees:         roleNames(stmt.GetGrantees()),
+		Statement:        stmt,
+	}
+}
+
+// parseRoleStmt はCREATE ROLE / ALTER ROLE / ALTER ROLE ... RENAME TO / DROP ROLE文を抽出する
+func (p *statementParser) parseRoleStmt(node *pg_query.Node, location SQLStatement) {
+	switch {
+	case node.GetCreateRoleStmt() != nil:
+		p.statements = append(p.statements, &RoleStatement{
+			SQLStatement: location,
+			RoleName:     node.GetCreateRoleStmt().GetRole(),
+			Create:       true,
+			Attributes:   roleAttributes(node.GetCreateRoleStmt().GetOptions()),
+			Statement:    node,
+		})
+	case node.GetAlterRoleStmt() != nil:
+		names := roleNames([]*pg_query.Node{{Node: &pg_query.Node_RoleSpec{RoleSpec: node.GetAlterRoleStmt().GetRole()}}})
+		if len(names) == 0 {
+			return
+		}
+		p.statements = append(p.statements, &RoleStatement{
+			SQLStatement: location,
+			RoleName:     names[0],
+			Attributes:   roleAttributes(node.GetAlterRoleStmt().GetOptions()),
+			Statement:    node,
+		})
+	case node.GetRenameStmt() != nil:
+		p.statements = append(p.statements, &RoleStatement{
+			SQLStatement: location,
+			RoleName:     node.GetRenameStmt().GetSubname(),
+			NewName:      node.GetRenameStmt().GetNewname(),
+			Statement:    node,
+		})
+	case node.GetDropRoleStmt() != nil:
+		for _, name := range roleNames(node.GetDropRoleStmt().GetRoles()) {
+			p.statements = append(p.statements, &RoleStatement{
 				SQLStatement: location,
-				TableName:    tableName,
-				PolicyName:   policyName,
-				Statement:    res,
+				RoleName:     name,
+				Drop:         true,
+				Statement:    node,
 			})
 		}
 	}
+}
+
+// roleAttributes はCREATE ROLE / ALTER ROLEのオプションから真偽値の属性を抽出する
+func roleAttributes(options []*pg_query.Node) map[string]bool {
+	attributes := make(map[string]bool)
+	for _, option := range options {
+		if value := option.GetDefElem().GetArg().GetBoolean(); value != nil {
+			attributes[option.GetDefElem().GetDefname()] = value.GetBoolval()
+		}
+	}
+	return attributes
+}
 
-	return policies
+// parseCreateFunctionStmt はCREATE FUNCTION / CREATE PROCEDURE文を抽出する
+func (p *statementParser) parseCreateFunctionStmt(stmt *pg_query.CreateFunctionStmt, location SQLStatement) {
+	names := nameList(stmt.GetFuncname())
+	if len(names) == 0 {
+		return
+	}
+	schemaName, _ := resolveSchemaName(qualifierOf(names), p.searchPath)
+
+	// 本体の未修飾名は関数に設定されたsearch_path（ない場合は定義時のsearch_path）で解決する
+	var searchPath []string
+	for _, option := range stmt.GetOptions() {
+		set := option.GetDefElem().GetArg().GetVariableSetStmt()
+		if option.GetDefElem().GetDefname() != "set" || set.GetName() != "search_path" {
+			continue
+		}
+		switch set.GetKind() {
+		case pg_query.VariableSetKind_VAR_SET_VALUE:
+			searchPath = make([]string, 0, len(set.GetArgs()))
+			for _, arg := range set.GetArgs() {
+				searchPath = append(searchPath, arg.GetAConst().GetSval().GetSval())
+			}
+		case pg_query.VariableSetKind_VAR_SET_CURRENT:
+			// SET search_path FROM CURRENT は定義時のsearch_pathを固定する
+			searchPath = p.searchPath
+		}
+	}
+	resolvePath := searchPath
+	if resolvePath == nil {
+		resolvePath = p.searchPath
+	}
+
+	tables := make([]FunctionTableAccess, 0)
+	for _, query := range functionBody(stmt) {
+		filtered, columns := hasRowFilter(query), filterColumns(query)
+		for _, relation := range collectRangeVars(query) {
+			tables = append(tables, FunctionTableAccess{
+				TableReference: *tableReferenceIn(relation, resolvePath),
+				Filtered:       filtered,
+				FilterColumns:  columns,
+			})
+		}
+	}
+
+	p.statements = append(p.statements, &FunctionStatement{
+		SQLStatement:    location,
+		FunctionName:    names[len(names)-1],
+		SchemaName:      schemaName,
+		Replace:         stmt.GetReplace(),
+		SecurityDefiner: functionOption(stmt, "security").GetBoolean().GetBoolval(),
+		SearchPath:      searchPath,
+		Tables:          tables,
+		Statement:       stmt,
+	})
+}
+
+// parseVariableSetStmt はSET search_path文を解析して現在のsearch_pathを更新し、
+// RLSの適用に影響するセッションの設定の変更を抽出する
+func (p *statementParser) parseVariableSetStmt(stmt *pg_query.VariableSetStmt, node *pg_query.Node, location SQLStatement) {
+	if sessionSettings[stmt.GetName()] {
+		var value string
+		if stmt.GetKind() == pg_query.VariableSetKind_VAR_SET_VALUE && len(stmt.GetArgs()) > 0 {
+			value = settingValue(stmt.GetArgs()[0])
+		}
+		p.statements = append(p.statements, &SessionSettingStatement{
+			SQLStatement: location,
+			Name:         stmt.GetName(),
+			Value:        value,
+			Local:        stmt.GetIsLocal(),
+			Statement:    node,
+		})
+	}
+
+	// SET LOCAL はトランザクションの終了まで有効だが、トランザクションの境界は追跡せずSETと同様に扱う
+	switch stmt.GetKind() {
+	case pg_query.VariableSetKind_VAR_SET_VALUE:
+		if stmt.GetName() != "search_path" {
+			return
+		}
+		searchPath := make([]string, 0, len(stmt.GetArgs()))
+		for _, arg := range stmt.GetArgs() {
+			if schema := arg.GetAConst().GetSval().GetSval(); schema != "" {
+				searchPath = append(searchPath, schema)
+			}
+		}
+		p.searchPath = searchPath
+	case pg_query.VariableSetKind_VAR_SET_DEFAULT, pg_query.VariableSetKind_VAR_RESET:
+		if stmt.GetName() == "search_path" {
+			p.searchPath = p.defaultSearchPath
+		}
+	case pg_query.VariableSetKind_VAR_RESET_ALL:
+		p.searchPath = p.defaultSearchPath
+	}
+}
+
+// parseSetConfigCalls はクエリに含まれる set_config('row_security', 'off', false) などの呼び出しを抽出する
+// 設定名と値が定数の場合のみ対象にする
+func (p *statementParser) parseSetConfigCalls(query *pg_query.Node, location SQLStatement) {
+	walkAST(query, func(n proto.Message) bool {
+		call, ok := n.(*pg_query.FuncCall)
+		if !ok || len(call.GetArgs()) != 3 {
+			return true
+		}
+		names := nameList(call.GetFuncname())
+		if names[len(names)-1] != "set_config" || (len(names) > 1 && names[0] != "pg_catalog") {
+			return true
+		}
+
+		name := strings.ToLower(call.GetArgs()[0].GetAConst().GetSval().GetSval())
+		if !sessionSettings[name] || call.GetArgs()[1].GetAConst() == nil {
+			return true
+		}
+		local, _ := constantBool(call.GetArgs()[2])
+		p.statements = append(p.statements, &SessionSettingStatement{
+			SQLStatement: location,
+			Name:         name,
+			Value:        settingValue(call.GetArgs()[1]),
+			Local:        local,
+			Statement:    query,
+		})
+		return true
+	})
+}
+
+// sessionSettings はRLSの適用に影響するセッションの設定
+var sessionSettings = map[string]bool{
+	"row_security":          true,
+	"role":                  true,
+	"session_authorization": true,
+}
+
+// settingValue は設定値の定数を文字列として返す
+func settingValue(arg *pg_query.Node) string {
+	value := arg.GetAConst()
+	switch {
+	case value.GetSval() != nil:
+		return value.GetSval().GetSval()
+	case value.GetIval() != nil:
+		return strconv.Itoa(int(value.GetIval().GetIval()))
+	case value.GetBoolval() != nil:
+		return strconv.FormatBool(value.GetBoolval().GetBoolval())
+	}
+	return ""
+}
+
+// parseCreateSchemaStmt はCREATE SCHEMA文に含まれる要素を抽出する
+// 要素内の未修飾名は作成されるスキーマを先頭にしたsearch_pathで解決される
+func (p *statementParser) parseCreateSchemaStmt(stmt *pg_query.CreateSchemaStmt, location SQLStatement) {
+	schemaName := stmt.GetSchemaname()
+	if schemaName == "" {
+		// スキーマ名が省略された場合はAUTHORIZATIONのロール名がスキーマ名になる
+		schemaName = stmt.GetAuthrole().GetRolename()
+	}
+	if len(stmt.GetSchemaElts()) == 0 {
+		return
+	}
+
+	outerSearchPath := p.searchPath
+	if schemaName != "" {
+		p.searchPath = append([]string{schemaName}, outerSearchPath...)
+	}
+	// AUTHORIZATION CURRENT_USERなどでスキーマ名が決まらない場合は現在のsearch_pathで解決する
+	for _, elt := range stmt.GetSchemaElts() {
+		p.parseStatement(elt, location)
+	}
+	p.searchPath = outerSearchPath
+}
+
+// tableReference はリレーションへの参照を現在のsearch_pathで作成する
+func (p *statementParser) tableReference(relation *pg_query.RangeVar) *TableReference {
+	return tableReferenceIn(relation, p.searchPath)
+}
+
+// tableReferences はクエリまたは式が参照するテーブルへの参照を現在のsearch_pathで作成する
+func (p *statementParser) tableReferences(node *pg_query.Node) []TableReference {
+	references := make([]TableReference, 0)
+	for _, relation := range collectRangeVars(node) {
+		references = append(references, *p.tableReference(relation))
+	}
+	return references
+}
+
+// qualifiedReference は修飾名で指定されたテーブルへの参照を現在のsearch_pathで作成する
+func (p *statementParser) qualifiedReference(names []string) TableReference {
+	schemaName, resolvePath := resolveSchemaName(qualifierOf(names), p.searchPath)
+	return TableReference{
+		TableName:  names[len(names)-1],
+		SchemaName: schemaName,
+		SearchPath: resolvePath,
+	}
+}
+
+// tableReferenceIn はリレーションへの参照を指定されたsearch_pathで作成する
+func tableReferenceIn(relation *pg_query.RangeVar, searchPath []string) *TableReference {
+	schemaName, resolvePath := resolveSchema(relation, searchPath)
+	return &TableReference{
+		TableName:  relation.GetRelname(),
+		SchemaName: schemaName,
+		SearchPath: resolvePath,
+	}
+}
+
+// resolveSchema はリレーションのスキーマ名を返す
+// 未修飾の場合はsearch_pathの先頭スキーマを返し、解決に使用したsearch_pathを併せて返す
+func resolveSchema(relation *pg_query.RangeVar, searchPath []string) (string, []string) {
+	return resolveSchemaName(relation.GetSchemaname(), searchPath)
+}
+
+// resolveSchemaName はスキーマ名を返す
+// 未修飾の場合はsearch_pathの先頭スキーマを返し、解決に使用したsearch_pathを併せて返す
+func resolveSchemaName(schema string, searchPath []string) (string, []string) {
+	if schema != "" {
+		return schema, nil
+	}
+	return creationSchema(searchPath), searchPath
+}
+
+// relOptions はWITH (...) / SET (...) で指定されたパラメータを名前と値の対応に変換する
+// 値を省略したパラメータ（WITH (security_invoker) など）の値は "true" とする
+func relOptions(items []*pg_query.Node) map[string]string {
+	options := make(map[string]string, len(items))
+	for _, item := range items {
+		option := item.GetDefElem()
+		if option == nil {
+			continue
+		}
+
+		value := "true"
+		switch arg := option.GetArg(); {
+		case arg == nil:
+		case arg.GetString_() != nil:
+			value = arg.GetString_().GetSval()
+		case arg.GetInteger() != nil:
+			value = strconv.Itoa(int(arg.GetInteger().GetIval()))
+		case arg.GetFloat() != nil:
+			value = arg.GetFloat().GetFval()
+		case arg.GetBoolean() != nil:
+			value = strconv.FormatBool(arg.GetBoolean().GetBoolval())
+		case arg.GetTypeName() != nil:
+			// on / off などのキーワードは型名として解析される
+			value = nameList(arg.GetTypeName().GetNames())[len(arg.GetTypeName().GetNames())-1]
+		}
+		options[option.GetDefname()] = value
+	}
+	return options
+}
+
+// roleNames はRoleSpecのリストをロール名のスライスに変換する
+// PUBLICやCURRENT_USERなどの特別なロールは小文字のキーワードで表す
+func roleNames(roles []*pg_query.Node) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		spec := role.GetRoleSpec()
+		if spec == nil {
+			continue
+		}
+
+		switch spec.GetRoletype() {
+		case pg_query.RoleSpecType_ROLESPEC_CSTRING:
+			names = append(names, spec.GetRolename())
+		case pg_query.RoleSpecType_ROLESPEC_PUBLIC:
+			names = append(names, "public")
+		case pg_query.RoleSpecType_ROLESPEC_CURRENT_USER:
+			names = append(names, "current_user")
+		case pg_query.RoleSpecType_ROLESPEC_SESSION_USER:
+			names = append(names, "session_user")
+		case pg_query.RoleSpecType_ROLESPEC_CURRENT_ROLE:
+			names = append(names, "current_role")
+		}
+	}
+	return names
+}
+
+// nameList はString要素のリストで表された修飾名を文字列のスライスに変換する
+func nameList(items []*pg_query.Node) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.GetString_().GetSval())
+	}
+	return names
+}
+
+// qualifierOf は修飾名のスキーマ部分を返す（未修飾の場合は空）
+func qualifierOf(names []string) string {
+	if len(names) < 2 {
+		return ""
+	}
+	return names[len(names)-2]
+}
+
+// creationSchema はsearch_pathの中でテーブルが作成されるスキーマを返す
+func creationSchema(searchPath []string) string {
+	for _, schema := range searchPath {
+		// "$user" はロール名と同じスキーマを指すが、静的解析では解決できないため読み飛ばす
+		if schema != "$user" && schema != "" {
+			return schema
+		}
+	}
+	return DefaultSearchPath[0]
 }
@@ -7,6 +7,15 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// mustExtractStatements はテスト用にステートメントを抽出して種類ごとに分類する
+func mustExtractStatements(t *testing.T, sql string, tree *pg_query.ParseResult) ([]TableDefinition, []RLSEnableStatement, []PolicyStatement) {
+	t.Helper()
+
+	parser, err := extractStatements("test.sql", sql, tree, DefaultSearchPath)
+	assert.NoError(t, err)
+	return splitStatements(parser.statements)
+}
+
 func TestParseSQL_Empty(t *testing.T) {
 	// 空のSQLをテスト
 	tables, rlsEnables, policies, err := ParseSQL("test.sql", "")
@@ -54,6 +63,8 @@ func TestParseSQL_CompleteSQL(t *testing.T) {
 	// ポリシーの検証
 	assert.Equal(t, "accounts", policies[0].TableName)
 	assert.Equal(t, "account_managers", policies[0].PolicyName)
+	assert.Equal(t, "all", policies[0].Command)
+	assert.False(t, policies[0].Restrictive)
 }
 
 func TestExtractTableDefinitions(t *testing.T) {
@@ -96,7 +107,7 @@ func TestExtractTableDefinitions(t *testing.T) {
 			assert.NoError(t, err)
 
 			// テーブル定義を抽出
-			tables := extractTableDefinitions("test.sql", tree)
+			tables, _, _ := mustExtractStatements(t, tc.sql, tree)
 
 			// 検証
 			assert.Len(t, tables, tc.expectedCount)
@@ -154,7 +165,7 @@ func TestExtractRLSEnableStatements(t *testing.T) {
 			assert.NoError(t, err)
 
 			// RLS有効化文を抽出
-			rlsEnables := extractRLSEnableStatements("test.sql", tree)
+			_, rlsEnables, _ := mustExtractStatements(t, tc.sql, tree)
 
 			// 検証
 			assert.Len(t, rlsEnables, tc.expectedCount)
@@ -218,7 +229,7 @@ func TestExtractPolicyStatements(t *testing.T) {
 			assert.NoError(t, err)
 
 			// ポリシー文を抽出
-			policies := extractPolicyStatements("test.sql", tree)
+			_, _, policies := mustExtractStatements(t, tc.sql, tree)
 
 			// 検証
 			assert.Len(t, policies, tc.expectedCount)
@@ -308,3 +319,546 @@ CREATE POLICY account_policy ON accounts USING (true);`
 	assert.Less(t, tables[0].Line, rlsEnables[0].Line)
 	assert.Less(t, rlsEnables[0].Line, policies[0].Line)
 }
+
+func TestParseSQL_SchemaQualifiedNames(t *testing.T) {
+	// スキーマ修飾名と未修飾名の解決をテスト
+	sql := `CREATE TABLE auth.users (id int);
+CREATE TABLE users (id int);
+ALTER TABLE auth.users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON users USING (true);`
+
+	testCases := map[string]struct {
+		searchPath           []string
+		expectedCreateSchema string
+	}{
+		"default search_path": {
+			searchPath:           nil,
+			expectedCreateSchema: "public",
+		},
+		"custom search_path": {
+			searchPath:           []string{"$user", "app", "public"},
+			expectedCreateSchema: "app",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tables, rlsEnables, policies, err := ParseSQLWithOptions("test.sql", sql, ParseOptions{SearchPath: tc.searchPath})
+
+			assert.NoError(t, err)
+			assert.Len(t, tables, 2)
+			assert.Len(t, rlsEnables, 1)
+			assert.Len(t, policies, 1)
+
+			// 明示的なスキーマはそのまま保持される
+			assert.Equal(t, "auth", tables[0].SchemaName)
+			assert.Equal(t, "auth", rlsEnables[0].SchemaName)
+			assert.Nil(t, rlsEnables[0].SearchPath)
+
+			// 未修飾名はsearch_pathに従って解決される
+			assert.Equal(t, tc.expectedCreateSchema, tables[1].SchemaName)
+			assert.Equal(t, tc.expectedCreateSchema, policies[0].SchemaName)
+			assert.NotEmpty(t, policies[0].SearchPath)
+		})
+	}
+}
+
+func TestParseSQL_SetSearchPath(t *testing.T) {
+	// SET search_path による未修飾名の解決をテスト
+	sql := `CREATE TABLE before_set (id int);
+SET search_path TO tenant, public;
+CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (true);
+RESET search_path;
+CREATE TABLE after_reset (id int);`
+
+	tables, rlsEnables, policies, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, tables, 3)
+	assert.Len(t, rlsEnables, 1)
+	assert.Len(t, policies, 1)
+
+	assert.Equal(t, "public", tables[0].SchemaName)
+	assert.Equal(t, "tenant", tables[1].SchemaName)
+	assert.Equal(t, "public", tables[2].SchemaName)
+
+	assert.Equal(t, "tenant", rlsEnables[0].SchemaName)
+	assert.Equal(t, []string{"tenant", "public"}, rlsEnables[0].SearchPath)
+	assert.Equal(t, "tenant", policies[0].SchemaName)
+	assert.Equal(t, []string{"tenant", "public"}, policies[0].SearchPath)
+}
+
+func TestParseSQL_SetLocalSearchPath(t *testing.T) {
+	// SET LOCAL search_path はトランザクションの境界を考慮せずに以降のステートメントに反映される
+	sql := `BEGIN;
+SET LOCAL search_path TO tenant;
+CREATE TABLE accounts (id int);
+COMMIT;
+CREATE TABLE after_commit (id int);`
+
+	tables, _, _, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, tables, 2)
+	assert.Equal(t, "tenant", tables[0].SchemaName)
+	assert.Equal(t, "tenant", tables[1].SchemaName)
+}
+
+func TestParseSQL_CreateSchemaElements(t *testing.T) {
+	testCases := map[string]struct {
+		sql                string
+		expectedSchemaName string
+		expectedTableNames []string
+	}{
+		"schema with elements": {
+			sql:                `CREATE SCHEMA billing CREATE TABLE invoices (id int) CREATE TABLE payments (id int);`,
+			expectedSchemaName: "billing",
+			expectedTableNames: []string{"invoices", "payments"},
+		},
+		"schema named by authorization": {
+			sql:                `CREATE SCHEMA AUTHORIZATION joe CREATE TABLE notes (id int);`,
+			expectedSchemaName: "joe",
+			expectedTableNames: []string{"notes"},
+		},
+		"schema named by current user": {
+			sql:                `CREATE SCHEMA AUTHORIZATION CURRENT_USER CREATE TABLE x (id int);`,
+			expectedSchemaName: "public",
+			expectedTableNames: []string{"x"},
+		},
+		"schema without elements": {
+			sql:                `CREATE SCHEMA billing;`,
+			expectedSchemaName: "",
+			expectedTableNames: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tables, _, _, err := ParseSQL("test.sql", tc.sql)
+
+			assert.NoError(t, err)
+			assert.Len(t, tables, len(tc.expectedTableNames))
+			for i, table := range tables {
+				assert.Equal(t, tc.expectedTableNames[i], table.TableName)
+				assert.Equal(t, tc.expectedSchemaName, table.SchemaName)
+			}
+		})
+	}
+}
+
+func TestParseSQL_LineAndColumn(t *testing.T) {
+	// 先頭の空白やコメントを除いた最初のトークンの位置が設定されるかテスト
+	sql := `-- アカウントテーブル
+CREATE TABLE accounts (id int);
+
+  /* RLSの有効化 */ ALTER TABLE
+    "ユーザー" ENABLE ROW LEVEL SECURITY;`
+
+	tables, rlsEnables, _, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, tables, 1)
+	assert.Len(t, rlsEnables, 1)
+
+	assert.Equal(t, 2, tables[0].Line)
+	assert.Equal(t, 1, tables[0].Column)
+	assert.Equal(t, 2, tables[0].EndLine)
+	assert.Equal(t, 31, tables[0].EndColumn)
+	assert.Equal(t, 2, tables[0].NameLine)
+	assert.Equal(t, 14, tables[0].NameColumn)
+
+	assert.Equal(t, 4, rlsEnables[0].Line)
+	assert.Equal(t, 17, rlsEnables[0].Column)
+	assert.Equal(t, 5, rlsEnables[0].EndLine)
+	assert.Equal(t, 37, rlsEnables[0].EndColumn)
+	assert.Equal(t, 5, rlsEnables[0].NameLine)
+	assert.Equal(t, 5, rlsEnables[0].NameColumn)
+}
+
+func TestParseSQL_RLSActions(t *testing.T) {
+	sql := `ALTER TABLE accounts ENABLE ROW LEVEL SECURITY, FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts ADD COLUMN email text;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;`
+
+	_, rlsEnables, _, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	actions := []RLSAction{}
+	for _, rlsEnable := range rlsEnables {
+		actions = append(actions, rlsEnable.Action)
+		assert.Equal(t, "accounts", rlsEnable.TableName)
+	}
+	assert.Equal(t, []RLSAction{RLSEnable, RLSForce, RLSNoForce, RLSDisable}, actions)
+	assert.Equal(t, 1, rlsEnables[1].Line)
+	assert.Equal(t, 4, rlsEnables[3].Line)
+}
+
+func TestParseStatements_DropAndAlterPolicy(t *testing.T) {
+	sql := `DROP TABLE IF EXISTS logs, audit.events CASCADE;
+DROP POLICY IF EXISTS p ON auth.users;
+ALTER POLICY q ON accounts USING (manager = current_user);`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 4)
+
+	dropLogs, ok := statements[0].(*DropTableStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "logs", dropLogs.TableName)
+	assert.Equal(t, "public", dropLogs.SchemaName)
+	assert.NotEmpty(t, dropLogs.SearchPath)
+
+	dropEvents, ok := statements[1].(*DropTableStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "events", dropEvents.TableName)
+	assert.Equal(t, "audit", dropEvents.SchemaName)
+	assert.Nil(t, dropEvents.SearchPath)
+
+	dropPolicy, ok := statements[2].(*DropPolicyStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "users", dropPolicy.TableName)
+	assert.Equal(t, "auth", dropPolicy.SchemaName)
+	assert.Equal(t, "p", dropPolicy.PolicyName)
+	assert.Equal(t, 2, dropPolicy.Line)
+
+	alterPolicy, ok := statements[3].(*AlterPolicyStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "accounts", alterPolicy.TableName)
+	assert.Equal(t, "q", alterPolicy.PolicyName)
+	assert.Equal(t, 3, alterPolicy.Line)
+}
+
+func TestParseStatements_RenameAndSetSchema(t *testing.T) {
+	sql := `ALTER TABLE accounts RENAME TO customer_accounts;
+ALTER POLICY p ON auth.users RENAME TO q;
+ALTER TABLE accounts RENAME COLUMN a TO b;
+ALTER TABLE IF EXISTS x SET SCHEMA private;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 4)
+
+	renameTable, ok := statements[0].(*RenameStatement)
+	assert.True(t, ok)
+	assert.Equal(t, pg_query.ObjectType_OBJECT_TABLE, renameTable.ObjectType)
+	assert.Equal(t, "accounts", renameTable.TableName)
+	assert.Equal(t, "customer_accounts", renameTable.NewName)
+
+	renamePolicy, ok := statements[1].(*RenameStatement)
+	assert.True(t, ok)
+	assert.Equal(t, pg_query.ObjectType_OBJECT_POLICY, renamePolicy.ObjectType)
+	assert.Equal(t, "auth", renamePolicy.SchemaName)
+	assert.Equal(t, "p", renamePolicy.OldName)
+	assert.Equal(t, "q", renamePolicy.NewName)
+
+	renameColumn, ok := statements[2].(*RenameStatement)
+	assert.True(t, ok)
+	assert.Equal(t, pg_query.ObjectType_OBJECT_COLUMN, renameColumn.ObjectType)
+	assert.Equal(t, "a", renameColumn.OldName)
+	assert.Equal(t, "b", renameColumn.NewName)
+
+	setSchema, ok := statements[3].(*SetSchemaStatement)
+	assert.True(t, ok)
+	assert.Equal(t, "x", setSchema.TableName)
+	assert.Equal(t, "private", setSchema.NewSchemaName)
+	assert.Equal(t, 4, setSchema.Line)
+}
+
+func TestParseSQL_Partition(t *testing.T) {
+	sql := `CREATE TABLE events (id int, created_at date) PARTITION BY RANGE (created_at);
+CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01');
+CREATE TABLE archived_events () INHERITS (events);`
+
+	tables, _, _, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, tables, 3)
+	assert.Nil(t, tables[0].PartitionOf)
+	assert.Equal(t, &TableReference{TableName: "events", SchemaName: "public", SearchPath: DefaultSearchPath}, tables[1].PartitionOf)
+
+	// 通常の継承はパーティションとして扱わない
+	assert.Nil(t, tables[2].PartitionOf)
+}
+
+func TestParseSQL_DerivedTables(t *testing.T) {
+	sql := `CREATE TABLE report AS SELECT * FROM accounts WITH NO DATA;
+SELECT * INTO tmp FROM auth.users;
+CREATE MATERIALIZED VIEW IF NOT EXISTS account_summary AS SELECT count(*) FROM accounts;`
+
+	tables, _, _, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, tables, 3)
+
+	assert.Equal(t, TableKindTableAs, tables[0].Kind)
+	assert.Equal(t, "report", tables[0].TableName)
+	assert.False(t, tables[0].WithData)
+	assert.Equal(t, []TableReference{{TableName: "accounts", SchemaName: "public", SearchPath: DefaultSearchPath}}, tables[0].SourceTables)
+
+	assert.Equal(t, TableKindSelectInto, tables[1].Kind)
+	assert.Equal(t, "tmp", tables[1].TableName)
+	assert.True(t, tables[1].WithData)
+	assert.Equal(t, []TableReference{{TableName: "users", SchemaName: "auth"}}, tables[1].SourceTables)
+	assert.Equal(t, 2, tables[1].Line)
+
+	assert.Equal(t, TableKindMaterializedView, tables[2].Kind)
+	assert.Equal(t, "account_summary", tables[2].TableName)
+	assert.True(t, tables[2].IfNotExists)
+	assert.NotNil(t, tables[2].Query)
+}
+
+func TestParseSQL_Views(t *testing.T) {
+	sql := `CREATE OR REPLACE VIEW app.account_view WITH (security_invoker, security_barrier = off) AS SELECT * FROM accounts WHERE id > 0;
+ALTER VIEW app.account_view SET (security_invoker = true);
+ALTER VIEW app.account_view RESET (security_barrier);`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 3)
+
+	view := statements[0].(*TableDefinition)
+	assert.Equal(t, TableKindView, view.Kind)
+	assert.Equal(t, "app", view.SchemaName)
+	assert.Equal(t, "account_view", view.TableName)
+	assert.True(t, view.Replace)
+	assert.Equal(t, map[string]string{"security_invoker": "true", "security_barrier": "off"}, view.Options)
+	assert.Equal(t, []TableReference{{TableName: "accounts", SchemaName: "public", SearchPath: DefaultSearchPath}}, view.SourceTables)
+
+	set := statements[1].(*RelOptionsStatement)
+	assert.Equal(t, "account_view", set.TableName)
+	assert.Equal(t, map[string]string{"security_invoker": "true"}, set.Options)
+	assert.False(t, set.Reset)
+
+	reset := statements[2].(*RelOptionsStatement)
+	assert.Equal(t, map[string]string{"security_barrier": "true"}, reset.Options)
+	assert.True(t, reset.Reset)
+}
+
+func TestParseSQL_PolicyCommandAndKind(t *testing.T) {
+	sql := `CREATE POLICY s ON accounts FOR SELECT USING (tenant_id = 1);
+CREATE POLICY r ON accounts AS RESTRICTIVE FOR DELETE USING (tenant_id = 1);`
+
+	_, _, policies, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, policies, 2)
+	assert.Equal(t, "select", policies[0].Command)
+	assert.False(t, policies[0].Restrictive)
+	assert.Equal(t, "delete", policies[1].Command)
+	assert.True(t, policies[1].Restrictive)
+}
+
+func TestParseSQL_PolicyRoles(t *testing.T) {
+	sql := `CREATE POLICY p ON accounts USING (tenant_id = 1);
+CREATE POLICY q ON accounts TO app_user, CURRENT_USER USING (tenant_id = 1);`
+
+	_, _, policies, err := ParseSQL("test.sql", sql)
+
+	assert.NoError(t, err)
+	assert.Len(t, policies, 2)
+	assert.Equal(t, []string{"public"}, policies[0].Roles)
+	assert.Equal(t, []string{"app_user", "current_user"}, policies[1].Roles)
+}
+
+func TestParseSQL_Grants(t *testing.T) {
+	sql := `GRANT SELECT, UPDATE (name) ON accounts, auth.users TO anon, PUBLIC;
+REVOKE ALL ON ALL TABLES IN SCHEMA app FROM app_user;
+REVOKE UPDATE (name), DELETE ON accounts FROM anon;
+ALTER DEFAULT PRIVILEGES IN SCHEMA app GRANT SELECT ON TABLES TO app_user;
+GRANT USAGE ON SCHEMA app TO app_user;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 4)
+
+	grant := statements[0].(*GrantStatement)
+	assert.Equal(t, GrantTargetTables, grant.Target)
+	assert.True(t, grant.IsGrant)
+	assert.Equal(t, []TableReference{
+		{TableName: "accounts", SchemaName: "public", SearchPath: DefaultSearchPath},
+		{TableName: "users", SchemaName: "auth"},
+	}, grant.Tables)
+	assert.Equal(t, []string{"select"}, grant.Privileges)
+	assert.Equal(t, map[string][]string{"update": {"name"}}, grant.ColumnPrivileges)
+	assert.Equal(t, []string{"anon", "public"}, grant.Grantees)
+
+	revokeAll := statements[1].(*GrantStatement)
+	assert.Equal(t, GrantTargetAllTablesInSchema, revokeAll.Target)
+	assert.False(t, revokeAll.IsGrant)
+	assert.Equal(t, []string{"app"}, revokeAll.Schemas)
+	assert.Equal(t, []string{"all"}, revokeAll.Privileges)
+
+	// 列単位の権限はテーブル単位の権限と分けて記録する
+	revoke := statements[2].(*GrantStatement)
+	assert.Equal(t, []string{"delete"}, revoke.Privileges)
+	assert.Equal(t, map[string][]string{"update": {"name"}}, revoke.ColumnPrivileges)
+
+	defaults := statements[3].(*GrantStatement)
+	assert.Equal(t, GrantTargetDefaults, defaults.Target)
+	assert.Equal(t, []string{"app"}, defaults.Schemas)
+	assert.Equal(t, []string{"app_user"}, defaults.Grantees)
+}
+
+func TestParseSQL_Roles(t *testing.T) {
+	sql := `CREATE ROLE service WITH LOGIN BYPASSRLS;
+ALTER ROLE app NOSUPERUSER;
+DROP ROLE IF EXISTS old_admin, old_service;
+ALTER USER service RENAME TO worker;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 5)
+
+	create := statements[0].(*RoleStatement)
+	assert.Equal(t, "service", create.RoleName)
+	assert.True(t, create.Create)
+	assert.Equal(t, map[string]bool{"canlogin": true, "bypassrls": true}, create.Attributes)
+
+	alter := statements[1].(*RoleStatement)
+	assert.Equal(t, "app", alter.RoleName)
+	assert.False(t, alter.Create)
+	assert.Equal(t, map[string]bool{"superuser": false}, alter.Attributes)
+
+	assert.Equal(t, "old_admin", statements[2].(*RoleStatement).RoleName)
+	assert.True(t, statements[3].(*RoleStatement).Drop)
+
+	rename := statements[4].(*RoleStatement)
+	assert.Equal(t, "service", rename.RoleName)
+	assert.Equal(t, "worker", rename.NewName)
+	assert.False(t, rename.Create)
+	assert.False(t, rename.Drop)
+}
+
+func TestParseSQL_Functions(t *testing.T) {
+	sql := `SET search_path = app;
+CREATE OR REPLACE FUNCTION auth.list() RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ SELECT * FROM accounts WHERE tenant_id = 1 $$;
+CREATE FUNCTION purge() RETURNS void LANGUAGE plpgsql AS $$ BEGIN DELETE FROM members; END $$;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 2)
+
+	definer := statements[0].(*FunctionStatement)
+	assert.Equal(t, "list", definer.FunctionName)
+	assert.Equal(t, "auth", definer.SchemaName)
+	assert.True(t, definer.Replace)
+	assert.True(t, definer.SecurityDefiner)
+	assert.Equal(t, []string{"public"}, definer.SearchPath)
+	// 本体の未修飾名は関数のsearch_pathで解決する
+	assert.Equal(t, []FunctionTableAccess{
+		{TableReference: TableReference{TableName: "accounts", SchemaName: "public", SearchPath: []string{"public"}}, Filtered: true, FilterColumns: []string{"tenant_id"}},
+	}, definer.Tables)
+
+	invoker := statements[1].(*FunctionStatement)
+	assert.Equal(t, "app", invoker.SchemaName)
+	assert.False(t, invoker.SecurityDefiner)
+	assert.Nil(t, invoker.SearchPath)
+	assert.Equal(t, []FunctionTableAccess{
+		{TableReference: TableReference{TableName: "members", SchemaName: "app", SearchPath: []string{"app"}}, FilterColumns: []string{}},
+	}, invoker.Tables)
+}
+
+func TestParseSQL_SessionSettings(t *testing.T) {
+	sql := `SET LOCAL row_security TO off;
+SET SESSION AUTHORIZATION DEFAULT;
+SET statement_timeout = 0;
+SELECT set_config('role', 'admin', false);`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 3)
+
+	rowSecurity := statements[0].(*SessionSettingStatement)
+	assert.Equal(t, "row_security", rowSecurity.Name)
+	assert.Equal(t, "off", rowSecurity.Value)
+	assert.True(t, rowSecurity.Local)
+
+	authorization := statements[1].(*SessionSettingStatement)
+	assert.Equal(t, "session_authorization", authorization.Name)
+	assert.Equal(t, "", authorization.Value)
+
+	setConfig := statements[2].(*SessionSettingStatement)
+	assert.Equal(t, "role", setConfig.Name)
+	assert.Equal(t, "admin", setConfig.Value)
+	assert.False(t, setConfig.Local)
+	assert.Equal(t, 4, setConfig.Line)
+}
+
+func TestParseSQL_Columns(t *testing.T) {
+	sql := `CREATE TABLE accounts (id int, tenant_id int, PRIMARY KEY (id));
+CREATE TABLE copies (LIKE accounts);
+ALTER TABLE accounts ADD COLUMN manager text, DROP COLUMN IF EXISTS tenant_id;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 4)
+
+	assert.Equal(t, []string{"id", "tenant_id"}, statements[0].(*TableDefinition).Columns)
+	// LIKEで引き継ぐ列は特定できない
+	assert.Nil(t, statements[1].(*TableDefinition).Columns)
+
+	add := statements[2].(*ColumnStatement)
+	assert.Equal(t, "accounts", add.TableName)
+	assert.Equal(t, "manager", add.ColumnName)
+	assert.False(t, add.Drop)
+
+	drop := statements[3].(*ColumnStatement)
+	assert.Equal(t, "tenant_id", drop.ColumnName)
+	assert.True(t, drop.Drop)
+}
+
+func TestParseSQL_ForeignKeys(t *testing.T) {
+	sql := `CREATE TABLE orders (id int, account_id int REFERENCES accounts (id), user_id int, FOREIGN KEY (user_id) REFERENCES auth.users);
+ALTER TABLE orders ADD CONSTRAINT orders_invoice FOREIGN KEY (id) REFERENCES invoices, ADD PRIMARY KEY (id), DROP CONSTRAINT orders_account_id_fkey;`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 3)
+
+	// 制約名の省略時はPostgreSQLの既定の名前になる
+	assert.Equal(t, []ForeignKey{
+		{ConstraintName: "orders_account_id_fkey", References: TableReference{TableName: "accounts", SchemaName: "public", SearchPath: DefaultSearchPath}},
+		{ConstraintName: "orders_user_id_fkey", References: TableReference{TableName: "users", SchemaName: "auth"}},
+	}, statements[0].(*TableDefinition).ForeignKeys)
+
+	add := statements[1].(*ForeignKeyStatement)
+	assert.False(t, add.Drop)
+	assert.Equal(t, "orders_invoice", add.ForeignKey.ConstraintName)
+	assert.Equal(t, "invoices", add.ForeignKey.References.TableName)
+
+	drop := statements[2].(*ForeignKeyStatement)
+	assert.True(t, drop.Drop)
+	assert.Equal(t, "orders_account_id_fkey", drop.ForeignKey.ConstraintName)
+}
+
+func TestParseSQL_PolicySubqueryTables(t *testing.T) {
+	sql := `SET search_path = app;
+CREATE POLICY p ON public.members USING (team_id IN (SELECT id FROM teams)) WITH CHECK (EXISTS (SELECT 1 FROM auth.users));
+ALTER POLICY p ON public.members USING (true);`
+
+	statements, err := ParseStatements("test.sql", sql, ParseOptions{})
+
+	assert.NoError(t, err)
+	assert.Len(t, statements, 2)
+
+	// サブクエリの未修飾名はポリシー作成時のsearch_pathで解決する
+	policy := statements[0].(*PolicyStatement)
+	assert.Equal(t, []TableReference{{TableName: "teams", SchemaName: "app", SearchPath: []string{"app"}}}, policy.UsingTables)
+	assert.Equal(t, []TableReference{{TableName: "users", SchemaName: "auth"}}, policy.WithCheckTables)
+
+	alter := statements[1].(*AlterPolicyStatement)
+	assert.Empty(t, alter.UsingTables)
+	assert.Empty(t, alter.WithCheckTables)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// sourceIndex はソース内のバイトオフセットを行・列番号に変換するインデックス
+type sourceIndex struct {
+	sql        string
+	lineStarts []int                 // 各行の先頭のバイトオフセット
+	tokens     []*pg_query.ScanToken // コメントを除いたトークン（出現順）
+}
+
+// newSourceIndex はソースを走査してインデックスを作成する
+func newSourceIndex(sql string) (*sourceIndex, error) {
+	scanned, err := pg_query.Scan(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &sourceIndex{
+		sql:        sql,
+		lineStarts: []int{0},
+		tokens:     make([]*pg_query.ScanToken, 0, len(scanned.GetTokens())),
+	}
+
+	for i := 0; i < len(sql); i++ {
+		if sql[i] == '\n' {
+			index.lineStarts = append(index.lineStarts, i+1)
+		}
+	}
+
+	for _, token := range scanned.GetTokens() {
+		// コメントはステートメントの開始位置として扱わない
+		if token.GetToken() == pg_query.Token_SQL_COMMENT || token.GetToken() == pg_query.Token_C_COMMENT {
+			continue
+		}
+		index.tokens = append(index.tokens, token)
+	}
+
+	return index, nil
+}
+
+// position はバイトオフセットを1始まりの行番号とUTF-8の文字単位の列番号に変換する
+func (idx *sourceIndex) position(offset int) (line, column int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(idx.sql) {
+		offset = len(idx.sql)
+	}
+
+	// offset以下で最大の行頭を探す
+	lineIndex := sort.Search(len(idx.lineStarts), func(i int) bool {
+		return idx.lineStarts[i] > offset
+	}) - 1
+
+	column = utf8.RuneCountInString(idx.sql[idx.lineStarts[lineIndex]:offset]) + 1
+	return lineIndex + 1, column
+}
+
+// statementRange はステートメントの最初のトークンの開始位置と最後のトークンの終了位置を返す
+// stmtLocationとstmtLenはpg_queryが返す値で、先頭の空白やコメントを含む
+func (idx *sourceIndex) statementRange(stmtLocation, stmtLen int) (start, end int) {
+	limit := len(idx.sql)
+	if stmtLen > 0 {
+		limit = stmtLocation + stmtLen
+	}
+
+	start, end = stmtLocation, limit
+	first := sort.Search(len(idx.tokens), func(i int) bool {
+		return int(idx.tokens[i].GetStart()) >= stmtLocation
+	})
+	for i := first; i < len(idx.tokens) && int(idx.tokens[i].GetEnd()) <= limit; i++ {
+		if i == first {
+			start = int(idx.tokens[i].GetStart())
+		}
+		end = int(idx.tokens[i].GetEnd())
+	}
+
+	return start, end
+}
+
+// statementLocation はステートメントの位置情報を作成する
+func (idx *sourceIndex) statementLocation(filename string, stmtLocation, stmtLen int) SQLStatement {
+	start, end := idx.statementRange(stmtLocation, stmtLen)
+
+	location := SQLStatement{Filename: filename}
+	location.Line, location.Column = idx.position(start)
+	location.EndLine, location.EndColumn = idx.position(end)
+	return location
+}
+
+// withName はリレーション名の位置を設定した位置情報を返す
+func (idx *sourceIndex) withName(location SQLStatement, relation *pg_query.RangeVar) SQLStatement {
+	if relation == nil {
+		return location
+	}
+	location.NameLine, location.NameColumn = idx.position(int(relation.GetLocation()))
+	return location
+}
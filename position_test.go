@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceIndex_Position(t *testing.T) {
+	sql := "SELECT 1;\n-- ユーザー😊\nSELECT 'あ', 2;"
+
+	index, err := newSourceIndex(sql)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		offset         int
+		expectedLine   int
+		expectedColumn int
+	}{
+		"start of file": {
+			offset:         0,
+			expectedLine:   1,
+			expectedColumn: 1,
+		},
+		"newline character": {
+			offset:         9,
+			expectedLine:   1,
+			expectedColumn: 10,
+		},
+		"start of second line": {
+			offset:         10,
+			expectedLine:   2,
+			expectedColumn: 1,
+		},
+		"after multibyte characters": {
+			// "-- ユーザー😊\n" は3 + 4*3 + 4 + 1 = 20バイト
+			offset:         30 + len("SELECT 'あ', "),
+			expectedLine:   3,
+			expectedColumn: 13,
+		},
+		"end of file": {
+			offset:         len(sql),
+			expectedLine:   3,
+			expectedColumn: 15,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			line, column := index.position(tc.offset)
+			assert.Equal(t, tc.expectedLine, line)
+			assert.Equal(t, tc.expectedColumn, column)
+		})
+	}
+}
+
+func TestSourceIndex_StatementRange(t *testing.T) {
+	sql := "SELECT 1;\n  /* comment */ SELECT 2 -- trailing\n;"
+
+	index, err := newSourceIndex(sql)
+	assert.NoError(t, err)
+
+	// 2つ目のステートメントはセミコロン直後から始まり、空白とコメントを含む
+	start, end := index.statementRange(9, len(sql)-1-9)
+
+	assert.Equal(t, "SELECT 2", sql[start:end])
+}
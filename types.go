@@ -16,53 +16,346 @@ type LinterOptions struct {
 	Sources        []SourceFile // 入力ソース（複数可）
 	Writer         io.Writer    // 出力先
 	ExcludedTables []string     // 除外テーブル
+	SearchPath     []string     // 未修飾のテーブル名を解決するデフォルトのsearch_path
+	Rules          RuleOptions  // 検証ルールの設定
+}
+
+// RuleOptions は検証ルールの設定を表す構造体
+// ゼロ値はデフォルトの設定（オプトインのルールはすべて無効）を表す
+type RuleOptions struct {
+	RequireForceRLS        bool     // すべてのテーブルでFORCE ROW LEVEL SECURITYを必須にする
+	RequireForceRLSSchemas []string // FORCE ROW LEVEL SECURITYを必須にするスキーマ
+	InheritExclusion       bool     // 除外されたテーブルのパーティションも除外する
+	PublicTables           []string // すべての行の公開を意図したテーブル（rls-policy-always-trueの対象外）
+	CheckCommandCoverage   bool     // RLSが有効なテーブルでコマンドごとにPERMISSIVEのポリシーを必須にする
+	RequiredCommands       []string // PERMISSIVEのポリシーで許可されている必要があるコマンド（空の場合はPolicyCommandsのすべて）
+	RequireWithCheck       bool     // UPDATE / ALL のポリシーに明示的なWITH CHECKを必須にする
+	AppRoles               []string // アプリケーションが接続に使用するロール（RLSが有効なテーブルでポリシーを必須にする）
+	SensitiveTables        []string // TO PUBLIC のポリシーを許可しないテーブル
+	OnlyGrantedTables      bool     // 権限が付与されたテーブルのみを検証する
+	AdminRoles             []string // SUPERUSER / BYPASSRLS を許可する管理用のロール
+	TenantColumn           string   // RLSが有効なテーブルが持つテナントの列（空の場合はrls-tenant-columnを検証しない）
+	TenantExpression       string   // ポリシーでテナントの列と比較する式（空の場合は比較先を検証しない）
+}
+
+// ParseOptions はSQL解析時のオプションを表す構造体
+type ParseOptions struct {
+	SearchPath []string // 未修飾のテーブル名を解決するデフォルトのsearch_path
+}
+
+// QualifiedName はスキーマ修飾されたテーブル名を表す構造体
+type QualifiedName struct {
+	Schema string
+	Name   string
+}
+
+// String は "schema.table" 形式の文字列を返す
+func (q QualifiedName) String() string {
+	if q.Schema == "" {
+		return q.Name
+	}
+	return q.Schema + "." + q.Name
 }
 
 // LintResult は検証結果を表す構造体
 type LintResult struct {
-	Message  string `json:"message"`
-	Location struct {
-		File   string `json:"file"`
-		Line   int    `json:"line"`
-		Column int    `json:"column"`
-	} `json:"location"`
-	TableName string `json:"table_name"`
-	RuleID    string `json:"rule_id"`
+	Message   string   `json:"message"`
+	Location  Location `json:"location"`
+	TableName string   `json:"table_name"`
+	RuleID    string   `json:"rule_id"`
+	Severity  string   `json:"severity"`
+}
+
+const (
+	SeverityError   = "error"   // 権限が付与されたロールから保護されていない行を参照できる
+	SeverityWarning = "warning" // それ以外の設定の不足
+)
+
+// Location は検証結果の位置情報を表す構造体
+// 終了位置は範囲の直後の位置（排他的）を表す
+type Location struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"end_line,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
 }
 
 // SQLStatement はSQLステートメントの基本情報を表す構造体
+// 行・列番号は1始まりで、列番号はUTF-8の文字単位で数える
 type SQLStatement struct {
-	Filename string
-	Line     int
-	Column   int
+	Filename   string
+	Line       int // ステートメントの最初のトークンの行
+	Column     int // ステートメントの最初のトークンの列
+	EndLine    int // ステートメントの最後のトークンの直後の行
+	EndColumn  int // ステートメントの最後のトークンの直後の列
+	NameLine   int // 対象リレーション名の行
+	NameColumn int // 対象リレーション名の列
+}
+
+// Statement はソース順に再生される検証対象のステートメントを表すインターフェース
+type Statement interface {
+	statementInfo() SQLStatement
+}
+
+// statementInfo はステートメントの基本情報を返す
+func (s SQLStatement) statementInfo() SQLStatement {
+	return s
+}
+
+// TableReference はステートメントから参照されるテーブルを表す構造体
+type TableReference struct {
+	TableName  string
+	SchemaName string   // 参照先のスキーマ（未修飾の場合はsearch_pathの先頭）
+	SearchPath []string // 未修飾の場合に参照を解決するsearch_path
+}
+
+// TableKind はテーブルを作成したステートメントの種類を表す型
+type TableKind int
+
+const (
+	TableKindTable            TableKind = iota // CREATE TABLE
+	TableKindTableAs                           // CREATE TABLE ... AS
+	TableKindSelectInto                        // SELECT ... INTO
+	TableKindMaterializedView                  // CREATE MATERIALIZED VIEW
+	TableKindView                              // CREATE VIEW
+)
+
+// String はテーブルを作成したステートメントの表記を返す
+func (k TableKind) String() string {
+	switch k {
+	case TableKindTableAs:
+		return "CREATE TABLE AS"
+	case TableKindSelectInto:
+		return "SELECT INTO"
+	case TableKindMaterializedView:
+		return "CREATE MATERIALIZED VIEW"
+	case TableKindView:
+		return "CREATE VIEW"
+	default:
+		return "CREATE TABLE"
+	}
 }
 
 // TableDefinition はテーブル定義を表す構造体
 type TableDefinition struct {
 	SQLStatement
-	TableName string
-	Statement *pg_query.CreateStmt
+	Kind         TableKind // 作成したステートメントの種類（ゼロ値はCREATE TABLE）
+	TableName    string
+	SchemaName   string            // テーブルが作成されるスキーマ
+	PartitionOf  *TableReference   // PARTITION OF で指定された親テーブル（パーティションでない場合はnil）
+	IfNotExists  bool              // IF NOT EXISTS が指定されているか
+	Replace      bool              // OR REPLACE が指定されているか（ビューの場合）
+	WithData     bool              // クエリの結果で行が作成されるか（WITH NO DATAの場合はfalse）
+	Query        *pg_query.Node    // テーブルの元になったクエリ（CREATE TABLEの場合はnil）
+	SourceTables []TableReference  // Queryが参照するテーブル
+	Options      map[string]string // WITH (...) で指定されたパラメータ（security_invokerなど）
+	Columns      []string          // 定義された列（LIKEや継承などで列を特定できない場合はnil）
+	ForeignKeys  []ForeignKey      // 定義された外部キー制約
+	Statement    *pg_query.CreateStmt
+}
+
+// RLSAction はALTER TABLEによるRLS設定の変更内容を表す型
+type RLSAction int
+
+const (
+	RLSEnable  RLSAction = iota // ENABLE ROW LEVEL SECURITY
+	RLSDisable                  // DISABLE ROW LEVEL SECURITY
+	RLSForce                    // FORCE ROW LEVEL SECURITY
+	RLSNoForce                  // NO FORCE ROW LEVEL SECURITY
+)
+
+// String はALTER TABLEのサブコマンドとしての表記を返す
+func (a RLSAction) String() string {
+	switch a {
+	case RLSDisable:
+		return "DISABLE ROW LEVEL SECURITY"
+	case RLSForce:
+		return "FORCE ROW LEVEL SECURITY"
+	case RLSNoForce:
+		return "NO FORCE ROW LEVEL SECURITY"
+	default:
+		return "ENABLE ROW LEVEL SECURITY"
+	}
 }
 
-// RLSEnableStatement はRLS有効化文を表す構造体
+// RLSEnableStatement はRLS設定を変更するALTER TABLE文を表す構造体
+// 1つのALTER TABLE文に複数のサブコマンドがある場合はサブコマンドごとに作成される
 type RLSEnableStatement struct {
 	SQLStatement
-	TableName string
+	TableReference
+	Action    RLSAction // 変更内容（ゼロ値はENABLE）
+	MissingOk bool      // IF EXISTS が指定されているか
 	Statement *pg_query.AlterTableStmt
 }
 
 // PolicyStatement はポリシー定義を表す構造体
 type PolicyStatement struct {
 	SQLStatement
-	TableName  string
+	TableReference
+	PolicyName      string
+	Command         string           // FOR で指定されたコマンド（all / select / insert / update / delete）
+	Restrictive     bool             // AS RESTRICTIVE が指定されているか（ゼロ値はPERMISSIVE）
+	Roles           []string         // TO で指定されたロール（PUBLICは "public"、CURRENT_USERなどは小文字のキーワード）
+	UsingTables     []TableReference // USING のサブクエリが参照するテーブル
+	WithCheckTables []TableReference // WITH CHECK のサブクエリが参照するテーブル
+	Statement       *pg_query.CreatePolicyStmt
+}
+
+// DropTableStatement はDROP TABLE文で削除されるテーブルを表す構造体
+// 1つのDROP TABLE文で複数のテーブルを削除する場合はテーブルごとに作成される
+type DropTableStatement struct {
+	SQLStatement
+	TableReference
+	Statement *pg_query.DropStmt
+}
+
+// DropPolicyStatement はDROP POLICY文を表す構造体
+type DropPolicyStatement struct {
+	SQLStatement
+	TableReference
 	PolicyName string
-	Statement  *pg_query.CreatePolicyStmt
+	Statement  *pg_query.DropStmt
+}
+
+// AlterPolicyStatement はALTER POLICY文を表す構造体
+type AlterPolicyStatement struct {
+	SQLStatement
+	TableReference
+	PolicyName      string
+	UsingTables     []TableReference // 変更後の USING のサブクエリが参照するテーブル
+	WithCheckTables []TableReference // 変更後の WITH CHECK のサブクエリが参照するテーブル
+	Statement       *pg_query.AlterPolicyStmt
+}
+
+// RenameStatement はALTER TABLE ... RENAME TO 文とALTER POLICY ... RENAME TO 文を表す構造体
+type RenameStatement struct {
+	SQLStatement
+	TableReference
+	ObjectType pg_query.ObjectType // 名前を変更するオブジェクトの種類（OBJECT_TABLE / OBJECT_POLICY / OBJECT_COLUMN）
+	OldName    string              // 変更前のポリシー名（テーブルの場合は空）
+	NewName    string              // 変更後の名前
+	Statement  *pg_query.RenameStmt
+}
+
+// SetSchemaStatement はALTER TABLE ... SET SCHEMA 文を表す構造体
+type SetSchemaStatement struct {
+	SQLStatement
+	TableReference
+	NewSchemaName string // 移動先のスキーマ
+	Statement     *pg_query.AlterObjectSchemaStmt
+}
+
+// RelOptionsStatement はALTER TABLE / ALTER VIEW ... SET (...) / RESET (...) 文を表す構造体
+type RelOptionsStatement struct {
+	SQLStatement
+	TableReference
+	Options   map[string]string // 設定するパラメータ（RESETの場合は値は空）
+	Reset     bool              // RESETの場合はtrue
+	Statement *pg_query.AlterTableStmt
+}
+
+// ForeignKey は外部キー制約を表す構造体
+type ForeignKey struct {
+	ConstraintName string         // 制約名（省略された場合はPostgreSQLの既定の名前）
+	References     TableReference // 参照先のテーブル
+}
+
+// ForeignKeyStatement はALTER TABLE ... ADD CONSTRAINT ... FOREIGN KEY / DROP CONSTRAINT 文を表す構造体
+type ForeignKeyStatement struct {
+	SQLStatement
+	TableReference
+	ForeignKey ForeignKey
+	Drop       bool // DROP CONSTRAINTの場合はtrue（References は空）
+	Statement  *pg_query.AlterTableStmt
+}
+
+// ColumnStatement はALTER TABLE ... ADD COLUMN / DROP COLUMN 文を表す構造体
+type ColumnStatement struct {
+	SQLStatement
+	TableReference
+	ColumnName string
+	Drop       bool // DROP COLUMNの場合はtrue
+	Statement  *pg_query.AlterTableStmt
+}
+
+// GrantTarget はGRANT / REVOKEの対象の種類を表す型
+type GrantTarget int
+
+const (
+	GrantTargetTables            GrantTarget = iota // ON TABLE ...
+	GrantTargetAllTablesInSchema                    // ON ALL TABLES IN SCHEMA ...
+	GrantTargetDefaults                             // ALTER DEFAULT PRIVILEGES ... ON TABLES
+)
+
+// GrantStatement はテーブルに対するGRANT / REVOKE文とALTER DEFAULT PRIVILEGES文を表す構造体
+type GrantStatement struct {
+	SQLStatement
+	Target           GrantTarget         // 対象の種類（ゼロ値はON TABLE）
+	IsGrant          bool                // GRANTの場合はtrue、REVOKEの場合はfalse
+	Tables           []TableReference    // 対象のテーブル（ON TABLEの場合）
+	Schemas          []string            // 対象のスキーマ（デフォルト権限でスキーマの指定がない場合は空）
+	Privileges       []string            // 小文字の権限名（ALL PRIVILEGESの場合は "all"）
+	ColumnPrivileges map[string][]string // 列単位の権限（小文字の権限名ごとの列名）
+	Grantees         []string            // 対象のロール（PUBLICは "public"）
+	Statement        *pg_query.GrantStmt
+}
+
+// RoleStatement はCREATE ROLE / ALTER ROLE / DROP ROLE文を表す構造体
+// 1つのDROP ROLE文で複数のロールを削除する場合はロールごとに作成される
+type RoleStatement struct {
+	SQLStatement
+	RoleName   string
+	Create     bool            // CREATE ROLE（CREATE USER / CREATE GROUPを含む）の場合はtrue
+	Drop       bool            // DROP ROLEの場合はtrue
+	NewName    string          // ALTER ROLE ... RENAME TO の変更後のロール名（名前の変更でない場合は空）
+	Attributes map[string]bool // 指定された属性（superuser / bypassrls など）
+	Statement  *pg_query.Node  // CreateRoleStmt / AlterRoleStmt / RenameStmt / DropRoleStmt
+}
+
+// SessionSettingStatement はRLSの適用に影響するセッションの設定の変更を表す構造体
+// SET row_security / SET ROLE / SET SESSION AUTHORIZATION と、同じ設定を変更するset_configの呼び出しが対象
+type SessionSettingStatement struct {
+	SQLStatement
+	Name      string         // row_security / role / session_authorization
+	Value     string         // 設定値（RESET / DEFAULT の場合は空）
+	Local     bool           // SET LOCAL またはトランザクション内のみの変更の場合はtrue
+	Statement *pg_query.Node // VariableSetStmt / SelectStmt
+}
+
+// FunctionTableAccess は関数の本体のクエリによるテーブルの参照を表す構造体
+type FunctionTableAccess struct {
+	TableReference
+	Filtered      bool     // 参照したクエリがWHERE句で行を絞り込んでいるか
+	FilterColumns []string // 参照したクエリのWHERE句（INSERTの場合は挿入先）の列名
+}
+
+// FunctionStatement はCREATE FUNCTION / CREATE PROCEDURE文を表す構造体
+type FunctionStatement struct {
+	SQLStatement
+	FunctionName    string
+	SchemaName      string                // 関数が作成されるスキーマ
+	Replace         bool                  // OR REPLACE が指定されているか
+	SecurityDefiner bool                  // SECURITY DEFINER が指定されているか
+	SearchPath      []string              // SET search_path で指定されたsearch_path（指定がない場合はnil）
+	Tables          []FunctionTableAccess // 本体のクエリが参照するテーブル（本体を解析できない場合は空）
+	Statement       *pg_query.CreateFunctionStmt
 }
 
 // TableInfo はテーブルに関する情報を統合した構造体
 type TableInfo struct {
-	TableName  string
+	Name       QualifiedName
 	Definition *TableDefinition
-	EnableRLS  *RLSEnableStatement
-	Policies   []*PolicyStatement
+	EnableRLS  *RLSEnableStatement // 現在有効なENABLE（無効化された場合はnil）
+	DisableRLS *RLSEnableStatement // ENABLEの後に実行されたDISABLE
+	ForceRLS   *RLSEnableStatement // 現在有効なFORCE（解除された場合はnil）
+	NoForceRLS *RLSEnableStatement // FORCEの後に実行されたNO FORCE
+	Policies   []*PolicyStatement  // 現在のポリシー（ALTER POLICYの変更を反映済み）
+	Parent     *TableInfo          // パーティションの親テーブル（パーティションでない場合はnil）
+	Sources    []*TableInfo        // 行のコピー元のテーブル（CREATE TABLE AS などの場合）
+	Options    map[string]string   // 現在のパラメータ（ALTER ... SET / RESET を反映済み）
+	Privileges privilegeSet        // ロールごとに付与されている権限
+	Columns    []string            // 現在の列（ALTER TABLEの変更を反映済み、列を特定できない場合はnil）
+	References []*foreignKeyInfo   // 現在の外部キー制約（ALTER TABLEの変更を反映済み）
+	sequence   int                 // 作成順（検証結果の出力順に使用）
 }
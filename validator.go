@@ -1,76 +1,1609 @@
 package main
 
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
+)
+
 // ValidateRLS はテーブル定義に対してRLS設定の検証を行う
+// ステートメントはファイルごとに行・列番号の順に並べ替えてから再生する
 func ValidateRLS(tables []TableDefinition, rlsEnables []RLSEnableStatement, policies []PolicyStatement, excludedTables []string) []LintResult {
-	// テーブル情報の統合
-	tableInfoMap := make(map[string]*TableInfo)
+	statements := make([]Statement, 0, len(tables)+len(rlsEnables)+len(policies))
+	for i := range tables {
+		statements = append(statements, &tables[i])
+	}
+	for i := range rlsEnables {
+		statements = append(statements, &rlsEnables[i])
+	}
+	for i := range policies {
+		statements = append(statements, &policies[i])
+	}
+
+	// ファイルの出現順を記録
+	fileOrder := make(map[string]int)
+	for _, stmt := range statements {
+		if _, exists := fileOrder[stmt.statementInfo().Filename]; !exists {
+			fileOrder[stmt.statementInfo().Filename] = len(fileOrder)
+		}
+	}
+
+	sort.SliceStable(statements, func(i, j int) bool {
+		a, b := statements[i].statementInfo(), statements[j].statementInfo()
+		if a.Filename != b.Filename {
+			return fileOrder[a.Filename] < fileOrder[b.Filename]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return Validate(statements, excludedTables, RuleOptions{})
+}
+
+// Validate はステートメントをソース順に再生し、入力の終端におけるテーブルの状態に対してRLS設定の検証を行う
+func Validate(statements []Statement, excludedTables []string, rules RuleOptions) []LintResult {
+	c := newCatalog(excludedTables)
+	c.inheritExclusion = rules.InheritExclusion
+
+	// ステートメントの再生
+	for _, stmt := range statements {
+		c.apply(stmt)
+	}
+
+	// 最終状態の検証
+	results := c.results
+	for _, info := range c.tableList() {
+		if c.isExcludedTable(info) {
+			continue
+		}
 
-	// テーブル定義の登録
-	for _, table := range tables {
-		if !isExcluded(table.TableName, excludedTables) {
-			tableInfoMap[table.TableName] = &TableInfo{
-				TableName:  table.TableName,
-				Definition: &table,
+		// 権限が付与されたロールから保護されていない行を参照できる場合は重大度を上げる
+		roles := grantedRoles(info, rules.AppRoles)
+		if rules.OnlyGrantedTables && len(roles) == 0 {
+			continue
+		}
+		for _, result := range validateTable(info, rules) {
+			if len(roles) > 0 && exposureRules[result.RuleID] {
+				result.Severity = SeverityError
+				result.Message += " (privileges granted to " + strings.Join(roles, ", ") + ")"
 			}
+			results = append(results, result)
 		}
+		results = append(results, validateForeignKeys(info)...)
 	}
 
-	// RLS有効化の登録
-	for _, rlsEnable := range rlsEnables {
-		if info, exists := tableInfoMap[rlsEnable.TableName]; exists {
-			info.EnableRLS = &rlsEnable
+	results = append(results, c.validatePolicyRecursion()...)
+	results = append(results, c.validateFunctions(rules)...)
+	results = append(results, c.validateRoles(rules)...)
+
+	for i := range results {
+		if results[i].Severity == "" {
+			results[i].Severity = SeverityWarning
 		}
 	}
 
-	// ポリシーの登録
-	for _, policy := range policies {
-		if info, exists := tableInfoMap[policy.TableName]; exists {
-			info.Policies = append(info.Policies, &policy)
+	return results
+}
+
+// exposureRules は保護されていない行を参照・変更できることを表すルール
+var exposureRules = map[string]bool{
+	"rls-not-enabled":           true,
+	"rls-disabled-after-enable": true,
+	"rls-partition-not-enabled": true,
+	"rls-derived-table":         true,
+	"view-bypasses-rls":         true,
+	"rls-policy-always-true":    true,
+}
+
+// rowPrivileges は行の参照・変更を可能にする権限
+var rowPrivileges = []string{"select", "insert", "update", "delete"}
+
+// grantedRoles はテーブルの行を参照・変更できる権限が付与されたロールを名前順に返す
+// アプリケーションのロールが指定されている場合は、そのロールとPUBLICのみを対象にする
+func grantedRoles(info *TableInfo, appRoles []string) []string {
+	roles := make([]string, 0)
+	for role, privileges := range info.Privileges {
+		if len(appRoles) > 0 && role != "public" && !slices.Contains(appRoles, role) {
+			continue
+		}
+		if hasRowPrivilege(privileges) {
+			roles = append(roles, role)
 		}
 	}
+	sort.Strings(roles)
+	return roles
+}
 
-	// 検証結果の作成
+// hasRowPrivilege は権限の集合に行の参照・変更を可能にする権限（列単位の権限を含む）があるかを返す
+func hasRowPrivilege(privileges map[string]bool) bool {
+	for name := range privileges {
+		privilege, _, _ := strings.Cut(name, "(")
+		if slices.Contains(rowPrivileges, privilege) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTable は1つのテーブルの最終状態に対してRLS設定の検証を行う
+func validateTable(info *TableInfo, rules RuleOptions) []LintResult {
 	results := make([]LintResult, 0)
 
-	for _, info := range tableInfoMap {
+	// ビューはRLSを設定できないため、参照先のRLSがバイパスされないかのみを検証する
+	if info.Definition.Kind == TableKindView {
+		return validateView(info)
+	}
+
+	// マテリアライズドビューにはRLSを設定できないため、コピー元の検証のみを行う
+	if info.Definition.Kind == TableKindMaterializedView {
+		if source := protectedSource(info); source != nil {
+			results = append(results, LintResult{
+				Message:   "Materialized view '" + info.Name.String() + "' copies rows from RLS-protected table '" + source.Name.String() + "', but materialized views cannot have RLS",
+				TableName: info.Name.String(),
+				RuleID:    "rls-derived-table",
+				Location:  statementLocation(info.Definition.SQLStatement),
+			})
+		}
+		return results
+	}
+
+	if info.ForceRLS == nil && info.NoForceRLS != nil {
+		// NO FORCEによってFORCEが解除された場合
+		results = append(results, LintResult{
+			Message:   "FORCE ROW LEVEL SECURITY on table '" + info.Name.String() + "' is removed by NO FORCE ROW LEVEL SECURITY",
+			TableName: info.Name.String(),
+			RuleID:    "rls-no-force-after-force",
+			Location:  statementLocation(info.NoForceRLS.SQLStatement),
+		})
+	} else if info.ForceRLS == nil && info.EnableRLS != nil && requiresForceRLS(info.Name, rules) {
+		// テーブル所有者にもRLSを適用するためのFORCEがない場合
+		results = append(results, LintResult{
+			Message:   "Table '" + info.Name.String() + "' has RLS enabled but not forced for the table owner",
+			TableName: info.Name.String(),
+			RuleID:    "rls-not-forced",
+			Location:  statementLocation(info.EnableRLS.SQLStatement),
+		})
+	}
+
+	if info.EnableRLS == nil && info.DisableRLS != nil {
+		// 有効化したRLSが後から無効化された場合
+		results = append(results, LintResult{
+			Message:   "RLS on table '" + info.Name.String() + "' is disabled after being enabled",
+			TableName: info.Name.String(),
+			RuleID:    "rls-disabled-after-enable",
+			Location:  statementLocation(info.DisableRLS.SQLStatement),
+		})
+	} else if info.EnableRLS == nil && info.Parent != nil && info.Parent.EnableRLS != nil {
+		// 親テーブルのRLSはパーティションを直接参照した場合には適用されない
+		results = append(results, LintResult{
+			Message:   "Partition '" + info.Name.String() + "' does not have RLS enabled while its parent '" + info.Parent.Name.String() + "' does",
+			TableName: info.Name.String(),
+			RuleID:    "rls-partition-not-enabled",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+	} else if source := protectedSource(info); info.EnableRLS == nil && source != nil && info.Definition.WithData {
+		// RLSで保護されたテーブルの行をRLSのないテーブルにコピーした場合
+		results = append(results, LintResult{
+			Message:   "Table '" + info.Name.String() + "' created by " + info.Definition.Kind.String() + " copies rows from RLS-protected table '" + source.Name.String() + "' without RLS enabled",
+			TableName: info.Name.String(),
+			RuleID:    "rls-derived-table",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+	} else if info.EnableRLS == nil {
 		// RLSが有効化されていない場合
-		if info.EnableRLS == nil {
+		results = append(results, LintResult{
+			Message:   "Table '" + info.Name.String() + "' does not have RLS enabled",
+			TableName: info.Name.String(),
+			RuleID:    "rls-not-enabled",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+	} else if len(info.Policies) == 0 {
+		// RLSは有効だがポリシーが設定されていない場合
+		results = append(results, LintResult{
+			Message:   "Table '" + info.Name.String() + "' has no RLS policy configured",
+			TableName: info.Name.String(),
+			RuleID:    "rls-no-policy",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+
+	}
+
+	// RLSが有効でポリシーがある場合は、コマンドごとに許可するポリシーがあるかを確認する
+	if info.EnableRLS != nil && len(info.Policies) > 0 {
+		permissive, restrictive := commandCoverage(info, false), commandCoverage(info, true)
+
+		// RESTRICTIVEのポリシーしかないコマンドはすべての行が拒否される
+		denied := make([]string, 0)
+		for _, command := range PolicyCommands {
+			if restrictive[command] && !permissive[command] {
+				denied = append(denied, command)
+			}
+		}
+		if len(denied) > 0 {
+			results = append(results, LintResult{
+				Message:   "Table '" + info.Name.String() + "' has only restrictive policies for " + strings.ToUpper(strings.Join(denied, ", ")) + ", which denies every row",
+				TableName: info.Name.String(),
+				RuleID:    "rls-only-restrictive",
+				Location:  statementLocation(info.Definition.SQLStatement),
+			})
+		}
+
+		// アプリケーションのロールに適用されるPERMISSIVEのポリシーがない場合はすべての行が拒否される
+		if roles := rolesWithoutPolicy(info, rules.AppRoles); len(roles) > 0 {
+			results = append(results, LintResult{
+				Message:   "Table '" + info.Name.String() + "' has no permissive policy for application roles " + strings.Join(roles, ", "),
+				TableName: info.Name.String(),
+				RuleID:    "rls-role-no-policy",
+				Location:  statementLocation(info.Definition.SQLStatement),
+			})
+		}
+
+		if rules.CheckCommandCoverage {
+			if missing := uncoveredCommands(permissive, restrictive, rules.RequiredCommands); len(missing) > 0 {
+				results = append(results, LintResult{
+					Message:   "Table '" + info.Name.String() + "' has no permissive policy for " + strings.ToUpper(strings.Join(missing, ", ")),
+					TableName: info.Name.String(),
+					RuleID:    "rls-command-uncovered",
+					Location:  statementLocation(info.Definition.SQLStatement),
+				})
+			}
+		}
+	}
+
+	// 書き込みを許可するポリシーのWITH CHECKを確認する
+	for _, policy := range info.Policies {
+		if message := withCheckProblem(policy, info.Name, rules); message != "" {
 			results = append(results, LintResult{
-				Message:   "Table '" + info.TableName + "' does not have RLS enabled",
-				TableName: info.TableName,
-				RuleID:    "rls-not-enabled",
-				Location: struct {
-					File   string `json:"file"`
-					Line   int    `json:"line"`
-					Column int    `json:"column"`
-				}{
-					File:   info.Definition.Filename,
-					Line:   info.Definition.Line,
-					Column: info.Definition.Column,
-				},
+				Message:   message,
+				TableName: info.Name.String(),
+				RuleID:    "rls-policy-missing-with-check",
+				Location:  statementLocation(policy.SQLStatement),
 			})
-		} else if len(info.Policies) == 0 {
-			// RLSは有効だがポリシーが設定されていない場合
+		}
+	}
+
+	// 機密性の高いテーブルではすべてのロールに行を許可するポリシーを認めない
+	if isExcludedTable(info.Name, rules.SensitiveTables) {
+		for _, policy := range info.Policies {
+			if !policy.Restrictive && appliesToRole(policy, "public") {
+				results = append(results, LintResult{
+					Message:   "Policy '" + policy.PolicyName + "' on sensitive table '" + info.Name.String() + "' is granted to PUBLIC",
+					TableName: info.Name.String(),
+					RuleID:    "rls-policy-public-role",
+					Location:  statementLocation(policy.SQLStatement),
+				})
+			}
+		}
+	}
+
+	// 設定されていないと実行時にエラーになるcurrent_settingの使い方を確認する
+	for _, policy := range info.Policies {
+		for _, message := range currentSettingProblems(policy, info.Name) {
+			results = append(results, LintResult{
+				Message:   message,
+				TableName: info.Name.String(),
+				RuleID:    "policy-current-setting-unsafe",
+				Location:  statementLocation(policy.SQLStatement),
+			})
+		}
+	}
+
+	// テナントの列による分離の規約を確認する
+	if rules.TenantColumn != "" && info.EnableRLS != nil {
+		results = append(results, validateTenantColumn(info, rules)...)
+	}
+
+	// すべての行を許可するポリシーは意図的に公開するテーブル以外では分離の役に立たない
+	if !isExcludedTable(info.Name, rules.PublicTables) {
+		for _, policy := range info.Policies {
+			if clause := alwaysTrueClause(policy); clause != "" {
+				results = append(results, LintResult{
+					Message:   "Permissive policy '" + policy.PolicyName + "' on table '" + info.Name.String() + "' has an always-true " + clause + " expression and grants every row",
+					TableName: info.Name.String(),
+					RuleID:    "rls-policy-always-true",
+					Location:  statementLocation(policy.SQLStatement),
+				})
+			}
+		}
+	}
+
+	return results
+}
+
+// PolicyCommands はポリシーの対象にできるコマンド
+var PolicyCommands = []string{"select", "insert", "update", "delete"}
+
+// commandCoverage はPERMISSIVE（restrictiveがtrueの場合はRESTRICTIVE）のポリシーが対象とするコマンドを集計する
+func commandCoverage(info *TableInfo, restrictive bool) map[string]bool {
+	covered := make(map[string]bool)
+	for _, policy := range info.Policies {
+		if policy.Restrictive != restrictive {
+			continue
+		}
+		switch command := strings.ToLower(policy.Command); command {
+		case "", "all":
+			// コマンドが不明なポリシーはCREATE POLICYの既定値（FOR ALL）とみなす
+			for _, c := range PolicyCommands {
+				covered[c] = true
+			}
+		default:
+			covered[command] = true
+		}
+	}
+	return covered
+}
+
+// rolesWithoutPolicy はアプリケーションのロールのうち、適用されるPERMISSIVEのポリシーがないものを返す
+func rolesWithoutPolicy(info *TableInfo, appRoles []string) []string {
+	missing := make([]string, 0)
+	for _, role := range appRoles {
+		covered := false
+		for _, policy := range info.Policies {
+			if !policy.Restrictive && appliesToRole(policy, role) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			missing = append(missing, role)
+		}
+	}
+	return missing
+}
+
+// appliesToRole はポリシーが指定されたロールに適用されるかを確認する
+// ロールが不明なポリシーはCREATE POLICYの既定値（TO PUBLIC）とみなす
+// ロールのメンバーシップは静的解析では解決できないため考慮しない
+func appliesToRole(policy *PolicyStatement, role string) bool {
+	if len(policy.Roles) == 0 {
+		return true
+	}
+	for _, name := range policy.Roles {
+		if name == role || name == "public" {
+			return true
+		}
+	}
+	return false
+}
+
+// uncoveredCommands は必須のコマンドのうち、許可するPERMISSIVEのポリシーがないものを返す
+// RESTRICTIVEのポリシーは行を絞り込むだけのため、コマンドを許可するポリシーとして数えない
+// RESTRICTIVEのポリシーのみがあるコマンドは rls-only-restrictive で報告するため除く
+func uncoveredCommands(permissive, restrictive map[string]bool, required []string) []string {
+	if len(required) == 0 {
+		required = PolicyCommands
+	}
+
+	missing := make([]string, 0)
+	for _, command := range required {
+		command = strings.ToLower(command)
+		if !permissive[command] && !restrictive[command] {
+			missing = append(missing, command)
+		}
+	}
+	return missing
+}
+
+// withCheckProblem はPERMISSIVEのポリシーのWITH CHECKの問題を説明するメッセージを返す（問題がない場合は空）
+// UPDATE / ALL のポリシーでWITH CHECKが省略された場合、PostgreSQLはUSINGを適用するため、明示を必須にする設定の場合のみ報告する
+func withCheckProblem(policy *PolicyStatement, name QualifiedName, rules RuleOptions) string {
+	if policy.Statement == nil || policy.Restrictive {
+		return ""
+	}
+
+	qual, withCheck := policy.Statement.GetQual(), policy.Statement.GetWithCheck()
+	switch strings.ToLower(policy.Command) {
+	case "insert":
+		if withCheck == nil {
+			return "INSERT policy '" + policy.PolicyName + "' on table '" + name.String() + "' has no WITH CHECK expression and allows inserting any row"
+		}
+	case "update", "all":
+		if withCheck == nil && rules.RequireWithCheck {
+			return "Policy '" + policy.PolicyName + "' on table '" + name.String() + "' for " + strings.ToUpper(policy.Command) + " has no explicit WITH CHECK expression"
+		}
+		if withCheck != nil && qual != nil && !equalExpr(qual, withCheck) {
+			return "Policy '" + policy.PolicyName + "' on table '" + name.String() + "' has a WITH CHECK expression that differs from USING, so rows can be written outside the rows it can access"
+		}
+	}
+	return ""
+}
+
+// alwaysTrueClause はPERMISSIVEのポリシーで常に真になる句（USING / WITH CHECK）を返す（ない場合は空）
+// RESTRICTIVEのポリシーは行を絞り込むだけのため、常に真でも行を許可しない
+func alwaysTrueClause(policy *PolicyStatement) string {
+	if policy.Statement == nil || policy.Restrictive {
+		return ""
+	}
+	if value, ok := constantBool(policy.Statement.GetQual()); ok && value {
+		return "USING"
+	}
+	if value, ok := constantBool(policy.Statement.GetWithCheck()); ok && value {
+		return "WITH CHECK"
+	}
+	return ""
+}
+
+// textTypes は文字列からの変換が失敗しない型
+var textTypes = []string{"text", "varchar", "bpchar", "name"}
+
+// currentSettingProblems はポリシーの式に含まれるcurrent_settingの呼び出しの問題を返す
+// missing_okのない呼び出しは設定がない場合に、NULLIFのない型変換は設定が空文字列の場合にエラーになる
+func currentSettingProblems(policy *PolicyStatement, table QualifiedName) []string {
+	// current_settingの結果を直接型変換している呼び出しと変換先の型
+	casts := make(map[*pg_query.FuncCall]string)
+	calls := make([]*pg_query.FuncCall, 0)
+	for _, expr := range []*pg_query.Node{policy.Statement.GetQual(), policy.Statement.GetWithCheck()} {
+		walkAST(expr, func(n proto.Message) bool {
+			switch n := n.(type) {
+			case *pg_query.TypeCast:
+				types := nameList(n.GetTypeName().GetNames())
+				if call := n.GetArg().GetFuncCall(); isCurrentSetting(call) && len(types) > 0 && !slices.Contains(textTypes, types[len(types)-1]) {
+					casts[call] = typeNameString(n.GetTypeName())
+				}
+			case *pg_query.FuncCall:
+				if isCurrentSetting(n) {
+					calls = append(calls, n)
+				}
+			}
+			return true
+		})
+	}
+
+	messages := make([]string, 0)
+	for _, call := range calls {
+		missingOk := false
+		if len(call.GetArgs()) > 1 {
+			// 定数でない指定は検証しない
+			value, ok := constantBool(call.GetArgs()[1])
+			missingOk = value || !ok
+		}
+		castType, cast := casts[call]
+		if missingOk && !cast {
+			continue
+		}
+
+		name := "..."
+		if setting := call.GetArgs()[0].GetAConst().GetSval(); setting != nil {
+			name = "'" + setting.GetSval() + "'"
+		}
+		problems := make([]string, 0, 2)
+		if !missingOk {
+			problems = append(problems, "calls current_setting("+name+") without missing_ok")
+		}
+		suggestion := "current_setting(" + name + ", true)"
+		if cast {
+			target := "current_setting(" + name + ")"
+			if !missingOk {
+				target = "it"
+			}
+			problems = append(problems, "casts "+target+" to "+castType+" without NULLIF")
+			suggestion = "NULLIF(" + suggestion + ", '')::" + castType
+		}
+
+		message := "Policy '" + policy.PolicyName + "' on table '" + table.String() + "' " + strings.Join(problems, " and ") + ", which raises an error when the setting is unset or empty; use " + suggestion
+		if !slices.Contains(messages, message) {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// isCurrentSetting は関数呼び出しがcurrent_settingかを確認する
+func isCurrentSetting(call *pg_query.FuncCall) bool {
+	names := nameList(call.GetFuncname())
+	if len(names) == 0 || len(call.GetArgs()) == 0 {
+		return false
+	}
+	return names[len(names)-1] == "current_setting" && (len(names) == 1 || names[0] == "pg_catalog")
+}
+
+// validateTenantColumn はテーブルがテナントの列を持ち、各ポリシーの句がその列を指定された式と比較しているかを検証する
+func validateTenantColumn(info *TableInfo, rules RuleOptions) []LintResult {
+	results := make([]LintResult, 0)
+	column := rules.TenantColumn
+
+	// 列がないテーブルのポリシーは列を参照できないため、列の不足のみを報告する
+	if info.Columns != nil && !slices.Contains(info.Columns, column) {
+		return append(results, LintResult{
+			Message:   "Table '" + info.Name.String() + "' has RLS enabled but no tenant column '" + column + "'",
+			TableName: info.Name.String(),
+			RuleID:    "rls-tenant-column",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+	}
+
+	// 式は実行時に検証済みのため、解析できない場合は比較先を確認しない
+	expected, _ := parseExpr(rules.TenantExpression)
+	for _, policy := range info.Policies {
+		clauses := []struct {
+			name string
+			expr *pg_query.Node
+		}{
+			{name: "USING", expr: policy.Statement.GetQual()},
+			{name: "WITH CHECK", expr: policy.Statement.GetWithCheck()},
+		}
+		for _, clause := range clauses {
+			if clause.expr == nil {
+				continue
+			}
+
+			referenced, compared := tenantComparison(clause.expr, info.Name, column, expected)
+			var message string
+			switch {
+			case !referenced:
+				message = "Policy '" + policy.PolicyName + "' on table '" + info.Name.String() + "' does not reference tenant column '" + column + "' in " + clause.name
+			case !compared:
+				message = "Policy '" + policy.PolicyName + "' on table '" + info.Name.String() + "' does not compare tenant column '" + column + "' to " + rules.TenantExpression + " in " + clause.name
+			default:
+				continue
+			}
 			results = append(results, LintResult{
-				Message:   "Table '" + info.TableName + "' has no RLS policy configured",
-				TableName: info.TableName,
-				RuleID:    "rls-no-policy",
-				Location: struct {
-					File   string `json:"file"`
-					Line   int    `json:"line"`
-					Column int    `json:"column"`
-				}{
-					File:   info.Definition.Filename,
-					Line:   info.Definition.Line,
-					Column: info.Definition.Column,
-				},
+				Message:   message,
+				TableName: info.Name.String(),
+				RuleID:    "rls-tenant-column",
+				Location:  statementLocation(policy.SQLStatement),
 			})
 		}
 	}
+	return results
+}
+
+// tenantComparison は式がテナントの列を参照しているか、列を期待する式と = で比較しているかを確認する
+// 期待する式がnilの場合は、列の参照があれば比較しているとみなす（サブクエリ内は対象外）
+func tenantComparison(expr *pg_query.Node, table QualifiedName, column string, expected *pg_query.Node) (referenced bool, compared bool) {
+	isColumn := func(node *pg_query.Node) bool {
+		name, ok := policyColumn(node.GetColumnRef(), table)
+		return ok && name == column
+	}
+
+	for _, ref := range columnRefs(expr) {
+		if name, ok := policyColumn(ref, table); ok && name == column {
+			referenced = true
+		}
+	}
+	if !referenced || expected == nil {
+		return referenced, referenced
+	}
+
+	walkAST(expr, func(n proto.Message) bool {
+		switch n := n.(type) {
+		case *pg_query.SelectStmt:
+			return false
+		case *pg_query.A_Expr:
+			names := nameList(n.GetName())
+			if n.GetKind() != pg_query.A_Expr_Kind_AEXPR_OP || len(names) != 1 || names[0] != "=" {
+				return true
+			}
+			if (isColumn(n.GetLexpr()) && equalExpr(n.GetRexpr(), expected)) || (isColumn(n.GetRexpr()) && equalExpr(n.GetLexpr(), expected)) {
+				compared = true
+			}
+		}
+		return !compared
+	})
+	return referenced, compared
+}
+
+// validateView はビューが参照先のテーブルのRLSをバイパスしていないかを検証する
+func validateView(info *TableInfo) []LintResult {
+	results := make([]LintResult, 0)
+
+	// security_invokerのビューは参照するユーザーの権限で実行されるため、参照先のRLSが適用される
+	source := protectedSource(info)
+	if source == nil || boolOption(info.Options, "security_invoker") {
+		return results
+	}
+
+	results = append(results, LintResult{
+		Message:   "View '" + info.Name.String() + "' reads RLS-protected table '" + source.Name.String() + "' with the view owner's privileges; set security_invoker = true",
+		TableName: info.Name.String(),
+		RuleID:    "view-bypasses-rls",
+		Location:  statementLocation(info.Definition.SQLStatement),
+	})
+
+	// WHERE句で行を絞り込むビューはsecurity_barrierでないと条件より先に評価される関数から行が漏れる
+	if hasWhereClause(info.Definition.Query) && !boolOption(info.Options, "security_barrier") {
+		results = append(results, LintResult{
+			Message:   "View '" + info.Name.String() + "' filters rows of RLS-protected table '" + source.Name.String() + "' with WHERE but is not a security_barrier view",
+			TableName: info.Name.String(),
+			RuleID:    "view-not-security-barrier",
+			Location:  statementLocation(info.Definition.SQLStatement),
+		})
+	}
 
 	return results
 }
 
+// protectedSource はテーブルのコピー元のうちRLSが有効なテーブルを返す（ない場合はnil）
+// コピー元がビューの場合はビューの参照先をたどる
+func protectedSource(info *TableInfo) *TableInfo {
+	return findProtectedSource(info, make(map[*TableInfo]bool))
+}
+
+// findProtectedSource は訪問済みのテーブルを除いてRLSが有効なコピー元を探す
+func findProtectedSource(info *TableInfo, visited map[*TableInfo]bool) *TableInfo {
+	visited[info] = true
+	for _, source := range info.Sources {
+		if visited[source] {
+			continue
+		}
+		if source.EnableRLS != nil {
+			return source
+		}
+		if source.Definition.Kind == TableKindView {
+			if protected := findProtectedSource(source, visited); protected != nil {
+				return protected
+			}
+		}
+	}
+	return nil
+}
+
+// hasWhereClause はクエリがWHERE句で行を絞り込んでいるかを確認する
+// UNIONなどの集合演算の場合はいずれかのSELECTがWHERE句を持つかを確認する
+func hasWhereClause(query *pg_query.Node) bool {
+	return selectHasWhereClause(query.GetSelectStmt())
+}
+
+// selectHasWhereClause はSELECT文またはその集合演算の要素がWHERE句を持つかを確認する
+func selectHasWhereClause(stmt *pg_query.SelectStmt) bool {
+	if stmt == nil {
+		return false
+	}
+	if stmt.GetWhereClause() != nil {
+		return true
+	}
+	return selectHasWhereClause(stmt.GetLarg()) || selectHasWhereClause(stmt.GetRarg())
+}
+
+// boolOption は真偽値のパラメータが有効かを確認する
+// 真偽値として解釈できない値は無効として扱う
+func boolOption(options map[string]string, name string) bool {
+	value, _ := parseBoolString(options[name])
+	return value
+}
+
+// requiresForceRLS はテーブルにFORCE ROW LEVEL SECURITYが必須かを確認する
+func requiresForceRLS(name QualifiedName, rules RuleOptions) bool {
+	if rules.RequireForceRLS {
+		return true
+	}
+	for _, schema := range rules.RequireForceRLSSchemas {
+		if schema == name.Schema {
+			return true
+		}
+	}
+	return false
+}
+
+// catalog はステートメントの再生中のテーブルの状態を保持する構造体
+type catalog struct {
+	excludedTables    []string
+	inheritExclusion  bool // パーティションが親テーブルの除外設定を引き継ぐか
+	tables            map[QualifiedName]*TableInfo
+	sequence          int                     // テーブルの作成順の採番
+	defaultPrivileges map[string]privilegeSet // スキーマごとのデフォルト権限（全スキーマの場合は空文字）
+	roles             []*roleInfo             // ロール（作成順）
+	functions         []*FunctionStatement    // 関数（作成順）
+	results           []LintResult            // 再生中に検出した検証結果
+}
+
+// newCatalog は空のカタログを作成する
+func newCatalog(excludedTables []string) *catalog {
+	return &catalog{
+		excludedTables:    excludedTables,
+		tables:            make(map[QualifiedName]*TableInfo),
+		defaultPrivileges: make(map[string]privilegeSet),
+		results:           make([]LintResult, 0),
+	}
+}
+
+// apply はステートメントを1つ再生してカタログの状態を更新する
+func (c *catalog) apply(stmt Statement) {
+	switch stmt := stmt.(type) {
+	case *TableDefinition:
+		c.applyTableDefinition(stmt)
+	case *RLSEnableStatement:
+		// ALTER TABLE IF EXISTS は存在しないテーブルを参照しても失敗しない
+		if stmt.MissingOk {
+			if info := c.find(stmt.TableReference); info != nil {
+				applyRLSAction(info, stmt)
+			}
+		} else if info := c.lookup(stmt.TableReference, stmt.SQLStatement, "ALTER TABLE ... "+stmt.Action.String()); info != nil {
+			applyRLSAction(info, stmt)
+		}
+	case *PolicyStatement:
+		if info := c.lookup(stmt.TableReference, stmt.SQLStatement, "CREATE POLICY "+stmt.PolicyName); info != nil {
+			c.checkPolicyColumns(info, stmt.SQLStatement, stmt.PolicyName, stmt.Statement.GetQual(), stmt.Statement.GetWithCheck())
+			info.Policies = append(info.Policies, stmt)
+		}
+	case *AlterPolicyStatement:
+		if info := c.lookup(stmt.TableReference, stmt.SQLStatement, "ALTER POLICY "+stmt.PolicyName); info != nil {
+			c.checkPolicyColumns(info, stmt.SQLStatement, stmt.PolicyName, stmt.Statement.GetQual(), stmt.Statement.GetWithCheck())
+			for i, policy := range info.Policies {
+				if policy.PolicyName == stmt.PolicyName {
+					info.Policies[i] = alterPolicy(policy, stmt)
+				}
+			}
+		}
+	case *DropPolicyStatement:
+		// 存在しないテーブルのポリシーの削除はRLS設定に影響しないため報告しない
+		if info := c.find(stmt.TableReference); info != nil {
+			policies := make([]*PolicyStatement, 0, len(info.Policies))
+			for _, policy := range info.Policies {
+				if policy.PolicyName != stmt.PolicyName {
+					policies = append(policies, policy)
+				}
+			}
+			info.Policies = policies
+		}
+	case *DropTableStatement:
+		if info := c.find(stmt.TableReference); info != nil {
+			c.drop(info)
+		}
+	case *RenameStatement:
+		c.applyRename(stmt)
+	case *SetSchemaStatement:
+		if info := c.find(stmt.TableReference); info != nil {
+			c.move(info, QualifiedName{Schema: stmt.NewSchemaName, Name: info.Name.Name})
+		}
+	case *GrantStatement:
+		c.applyGrant(stmt)
+	case *RoleStatement:
+		c.applyRole(stmt)
+	case *FunctionStatement:
+		c.applyFunction(stmt)
+	case *SessionSettingStatement:
+		c.applySessionSetting(stmt)
+	case *ForeignKeyStatement:
+		if info := c.find(stmt.TableReference); info != nil {
+			c.applyForeignKey(info, stmt)
+		}
+	case *ColumnStatement:
+		if info := c.find(stmt.TableReference); info != nil {
+			applyColumn(info, stmt)
+		}
+	case *RelOptionsStatement:
+		if info := c.find(stmt.TableReference); info != nil {
+			applyRelOptions(info, stmt)
+		}
+	}
+}
+
+// applyRename はテーブルまたはポリシーの名前の変更を反映する
+func (c *catalog) applyRename(stmt *RenameStatement) {
+	info := c.find(stmt.TableReference)
+	if info == nil {
+		return
+	}
+
+	switch stmt.ObjectType {
+	case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+		// テーブル名の変更ではスキーマは変わらない
+		c.move(info, QualifiedName{Schema: info.Name.Schema, Name: stmt.NewName})
+	case pg_query.ObjectType_OBJECT_POLICY:
+		for i, policy := range info.Policies {
+			if policy.PolicyName == stmt.OldName {
+				renamed := *policy
+				renamed.PolicyName = stmt.NewName
+				info.Policies[i] = &renamed
+			}
+		}
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		if info.Columns == nil {
+			return
+		}
+		// 列の一覧はステートメントと共有されることがあるため、新しいスライスを作成する
+		columns := make([]string, 0, len(info.Columns))
+		for _, column := range info.Columns {
+			if column == stmt.OldName {
+				column = stmt.NewName
+			}
+			columns = append(columns, column)
+		}
+		info.Columns = columns
+	}
+}
+
+// applyColumn はALTER TABLE ... ADD COLUMN / DROP COLUMN による列の変更を反映する
+func applyColumn(info *TableInfo, stmt *ColumnStatement) {
+	if info.Columns == nil {
+		return
+	}
+
+	columns := make([]string, 0, len(info.Columns)+1)
+	for _, column := range info.Columns {
+		if column != stmt.ColumnName {
+			columns = append(columns, column)
+		}
+	}
+	if !stmt.Drop {
+		columns = append(columns, stmt.ColumnName)
+	}
+	info.Columns = columns
+}
+
+// systemColumns はすべてのテーブルが持つシステム列
+var systemColumns = []string{"tableoid", "xmin", "cmin", "xmax", "cmax", "ctid"}
+
+// checkPolicyColumns はポリシーの式が参照する列がテーブルに存在するかを確認する
+// PostgreSQLはポリシーの作成時に列を解決するため、その時点の列に対して検証する
+func (c *catalog) checkPolicyColumns(info *TableInfo, stmt SQLStatement, policyName string, exprs ...*pg_query.Node) {
+	if info.Columns == nil || c.isExcludedTable(info) {
+		return
+	}
+
+	reported := make(map[string]bool)
+	for _, expr := range exprs {
+		for _, ref := range columnRefs(expr) {
+			column, ok := policyColumn(ref, info.Name)
+			if !ok || reported[column] || slices.Contains(info.Columns, column) || slices.Contains(systemColumns, column) {
+				continue
+			}
+			reported[column] = true
+			c.results = append(c.results, LintResult{
+				Message:   "Policy '" + policyName + "' on table '" + info.Name.String() + "' references unknown column '" + column + "'",
+				TableName: info.Name.String(),
+				RuleID:    "policy-unknown-column",
+				Location:  statementLocation(stmt),
+			})
+		}
+	}
+}
+
+// policyColumn はポリシーの式の列参照が対象テーブルの列を参照する場合にその列名を返す
+// テーブル名での修飾は対象テーブルの場合のみ確認し、テーブル名のみの参照は行全体の参照とみなす
+func policyColumn(ref *pg_query.ColumnRef, table QualifiedName) (string, bool) {
+	fields := make([]string, 0, len(ref.GetFields()))
+	for _, field := range ref.GetFields() {
+		if field.GetString_() == nil {
+			return "", false
+		}
+		fields = append(fields, field.GetString_().GetSval())
+	}
+
+	switch len(fields) {
+	case 1:
+		return fields[0], fields[0] != table.Name
+	case 2:
+		return fields[1], fields[0] == table.Name
+	case 3:
+		return fields[2], fields[0] == table.Schema && fields[1] == table.Name
+	}
+	return "", false
+}
+
+// move はテーブルの識別名を変更する
+// RLS設定とポリシーはPostgreSQLと同様に変更後のテーブルに引き継がれる
+func (c *catalog) move(info *TableInfo, name QualifiedName) {
+	delete(c.tables, info.Name)
+	info.Name = name
+	c.tables[name] = info
+}
+
+// alterPolicy はALTER POLICYによる変更を反映したポリシーを作成する
+// 変更後のポリシーの位置情報はALTER POLICY文の位置になる
+func alterPolicy(policy *PolicyStatement, stmt *AlterPolicyStatement) *PolicyStatement {
+	altered := *policy
+	altered.SQLStatement = stmt.SQLStatement
+
+	// 指定された句のみを置き換える
+	definition := &pg_query.CreatePolicyStmt{
+		PolicyName: policy.Statement.GetPolicyName(),
+		Table:      policy.Statement.GetTable(),
+		CmdName:    policy.Statement.GetCmdName(),
+		Permissive: policy.Statement.GetPermissive(),
+		Roles:      policy.Statement.GetRoles(),
+		Qual:       policy.Statement.GetQual(),
+		WithCheck:  policy.Statement.GetWithCheck(),
+	}
+	if policy.Statement == nil {
+		// ステートメントが不明なポリシーはCREATE POLICYの既定値（FOR ALL、PERMISSIVE）とみなす
+		definition.CmdName = "all"
+		definition.Permissive = true
+	}
+	if len(stmt.Statement.GetRoles()) > 0 {
+		definition.Roles = stmt.Statement.GetRoles()
+		altered.Roles = roleNames(definition.Roles)
+	}
+	if stmt.Statement.GetQual() != nil {
+		definition.Qual = stmt.Statement.GetQual()
+		altered.UsingTables = stmt.UsingTables
+	}
+	if stmt.Statement.GetWithCheck() != nil {
+		definition.WithCheck = stmt.Statement.GetWithCheck()
+		altered.WithCheckTables = stmt.WithCheckTables
+	}
+	altered.Statement = definition
+
+	return &altered
+}
+
+// applyRLSAction はRLS設定の変更をテーブルの状態に反映する
+func applyRLSAction(info *TableInfo, stmt *RLSEnableStatement) {
+	switch stmt.Action {
+	case RLSEnable:
+		info.EnableRLS = stmt
+		info.DisableRLS = nil
+	case RLSDisable:
+		// 有効化されていたRLSの無効化のみを後退として記録する
+		if info.EnableRLS != nil {
+			info.DisableRLS = stmt
+		}
+		info.EnableRLS = nil
+	case RLSForce:
+		info.ForceRLS = stmt
+		info.NoForceRLS = nil
+	case RLSNoForce:
+		if info.ForceRLS != nil {
+			info.NoForceRLS = stmt
+		}
+		info.ForceRLS = nil
+	}
+}
+
+// applySessionSetting はRLSの適用に影響するセッションの設定の変更をその位置に報告する
+// 以降のステートメントの実行に影響するため、入力の終端の状態ではなく変更ごとに報告する
+func (c *catalog) applySessionSetting(stmt *SessionSettingStatement) {
+	scope := "session"
+	if stmt.Local {
+		scope = "transaction"
+	}
+
+	switch stmt.Name {
+	case "row_security":
+		if enabled, ok := parseBoolString(stmt.Value); !ok || enabled {
+			return
+		}
+		c.results = append(c.results, LintResult{
+			Message:  "row_security is set to off for the rest of the " + scope + "; queries on RLS-enabled tables bypass policies for privileged roles and fail for others",
+			RuleID:   "session-row-security-off",
+			Location: statementLocation(stmt.SQLStatement),
+		})
+	case "role", "session_authorization":
+		if stmt.Value == "" || (stmt.Name == "role" && strings.EqualFold(stmt.Value, "none")) {
+			return
+		}
+		command := "SET ROLE"
+		switch {
+		case stmt.Statement.GetVariableSetStmt() == nil:
+			command = "set_config('" + stmt.Name + "')"
+		case stmt.Name == "session_authorization":
+			command = "SET SESSION AUTHORIZATION"
+		}
+		c.results = append(c.results, LintResult{
+			Message:  command + " switches to role '" + stmt.Value + "' for the rest of the " + scope + "; later statements run with that role's privileges and policies",
+			RuleID:   "session-role-switch",
+			Location: statementLocation(stmt.SQLStatement),
+		})
+	}
+}
+
+// policyStep はポリシーの展開の経路の1段階を表す構造体
+type policyStep struct {
+	table    *TableInfo
+	policy   *PolicyStatement
+	previous *policyStep
+}
+
+// validatePolicyRecursion はポリシーのサブクエリによる参照が循環していないかを検証する
+// PostgreSQLはサブクエリが参照するRLSが有効なテーブルのSELECTに適用されるポリシー（USING）を展開し、
+// 展開中のテーブルに戻る場合は実行時に infinite recursion detected in policy のエラーになる
+func (c *catalog) validatePolicyRecursion() []LintResult {
+	results := make([]LintResult, 0)
+	reported := make(map[string]bool)
+	for _, info := range c.tableList() {
+		if info.EnableRLS == nil || c.isExcludedTable(info) {
+			continue
+		}
+
+		for _, policy := range info.Policies {
+			// 最初のポリシーはコマンドに応じてUSINGとWITH CHECKの両方が評価される
+			start := &policyStep{table: info, policy: policy}
+			last := c.findPolicyCycle(start, append(append([]TableReference{}, policy.UsingTables...), policy.WithCheckTables...))
+			if last == nil {
+				continue
+			}
+
+			// 経路を復元し、同じテーブルの組み合わせの循環は一度だけ報告する
+			steps := make([]*policyStep, 0)
+			for step := last; step != nil; step = step.previous {
+				steps = append([]*policyStep{step}, steps...)
+			}
+			names := make([]string, 0, len(steps)+1)
+			tables := make([]string, 0, len(steps))
+			for _, step := range steps {
+				names = append(names, "'"+step.policy.PolicyName+"' on "+step.table.Name.String())
+				tables = append(tables, step.table.Name.String())
+			}
+			names = append(names, info.Name.String())
+			sort.Strings(tables)
+			key := strings.Join(tables, ",")
+			if reported[key] {
+				continue
+			}
+			reported[key] = true
+
+			results = append(results, LintResult{
+				Message:   "Policy subqueries form a cycle that causes infinite recursion at runtime: " + strings.Join(names, " -> "),
+				TableName: info.Name.String(),
+				RuleID:    "rls-policy-recursion",
+				Location:  statementLocation(policy.SQLStatement),
+			})
+		}
+	}
+	return results
+}
+
+// findPolicyCycle はポリシーの展開を幅優先でたどり、開始したテーブルを再び参照するポリシーまでの経路を返す（ない場合はnil）
+func (c *catalog) findPolicyCycle(start *policyStep, references []TableReference) *policyStep {
+	visited := make(map[*PolicyStatement]bool)
+	queue := []*policyStep{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		// 開始したポリシー以外はサブクエリのSELECTとして展開されるためUSINGのみが評価される
+		refs := current.policy.UsingTables
+		if current == start {
+			refs = references
+		}
+		for _, ref := range refs {
+			target := c.find(ref)
+			if target == nil || target.EnableRLS == nil {
+				continue
+			}
+			if target == start.table {
+				return current
+			}
+			for _, policy := range target.Policies {
+				if command := strings.ToLower(policy.Command); visited[policy] || (command != "" && command != "all" && command != "select") {
+					continue
+				}
+				visited[policy] = true
+				queue = append(queue, &policyStep{table: target, policy: policy, previous: current})
+			}
+		}
+	}
+	return nil
+}
+
+// applyFunction は関数の作成を反映する
+// CREATE OR REPLACE の場合は同じ名前の関数を置き換える（引数の型によるオーバーロードは区別しない）
+func (c *catalog) applyFunction(stmt *FunctionStatement) {
+	if stmt.Replace {
+		for i, function := range c.functions {
+			if function.SchemaName == stmt.SchemaName && function.FunctionName == stmt.FunctionName {
+				c.functions[i] = stmt
+				return
+			}
+		}
+	}
+	c.functions = append(c.functions, stmt)
+}
+
+// validateFunctions は入力の終端でRLSが有効なテーブルを参照するSECURITY DEFINERの関数を検証する
+// 関数は所有者の権限で実行されるため、所有者がテーブルの所有者の場合はRLSが適用されない
+// 行の絞り込みはWHERE句の有無で近似し、テナントの列が指定されている場合はその列の参照を必須にする
+func (c *catalog) validateFunctions(rules RuleOptions) []LintResult {
+	results := make([]LintResult, 0)
+	for _, function := range c.functions {
+		if !function.SecurityDefiner {
+			continue
+		}
+
+		// 参照するRLSが有効なテーブルと、絞り込まずに参照するテーブルを探す
+		var protected, unfiltered *TableInfo
+		for _, access := range function.Tables {
+			info := c.find(access.TableReference)
+			if info == nil || info.EnableRLS == nil {
+				continue
+			}
+			if protected == nil {
+				protected = info
+			}
+			filtered := access.Filtered
+			if rules.TenantColumn != "" {
+				filtered = slices.Contains(access.FilterColumns, rules.TenantColumn)
+			}
+			if unfiltered == nil && !filtered {
+				unfiltered = info
+			}
+		}
+		if protected == nil {
+			continue
+		}
+
+		problems := make([]string, 0, 2)
+		if function.SearchPath == nil {
+			problems = append(problems, "without SET search_path")
+		}
+		if unfiltered != nil {
+			protected = unfiltered
+			if rules.TenantColumn != "" {
+				problems = append(problems, "without filtering on tenant column '"+rules.TenantColumn+"'")
+			} else {
+				problems = append(problems, "without a WHERE clause")
+			}
+		}
+		if len(problems) == 0 {
+			continue
+		}
+
+		name := QualifiedName{Schema: function.SchemaName, Name: function.FunctionName}
+		results = append(results, LintResult{
+			Message:   "SECURITY DEFINER function '" + name.String() + "' accesses RLS-protected table '" + protected.Name.String() + "' " + strings.Join(problems, " and "),
+			TableName: protected.Name.String(),
+			RuleID:    "security-definer-bypasses-rls",
+			Location:  statementLocation(function.SQLStatement),
+		})
+	}
+	return results
+}
+
+// foreignKeyInfo は外部キー制約による参照を表す構造体
+type foreignKeyInfo struct {
+	name      string
+	parent    *TableInfo   // 参照先のテーブル
+	statement SQLStatement // 制約を追加したステートメント
+}
+
+// applyForeignKey はALTER TABLE ... ADD CONSTRAINT / DROP CONSTRAINT による外部キー制約の変更を反映する
+func (c *catalog) applyForeignKey(info *TableInfo, stmt *ForeignKeyStatement) {
+	if stmt.Drop {
+		references := make([]*foreignKeyInfo, 0, len(info.References))
+		for _, reference := range info.References {
+			if reference.name != stmt.ForeignKey.ConstraintName {
+				references = append(references, reference)
+			}
+		}
+		info.References = references
+		return
+	}
+
+	ref := stmt.ForeignKey.References
+	if parent := c.find(ref); parent != nil && parent != info {
+		info.References = append(info.References, &foreignKeyInfo{name: stmt.ForeignKey.ConstraintName, parent: parent, statement: stmt.SQLStatement})
+	}
+}
+
+// validateForeignKeys はRLSのないテーブルが外部キー制約でRLSが有効なテーブルを参照していないかを検証する
+// 参照先の行の有無が制約違反のエラーや結合から分かるため、RLSで隠された行の存在が漏れる
+// 外部キー制約を幅優先でたどり、最も近いRLSが有効なテーブルまでの経路を報告する
+func validateForeignKeys(info *TableInfo) []LintResult {
+	results := make([]LintResult, 0)
+	if info.EnableRLS != nil || len(info.References) == 0 {
+		return results
+	}
+
+	type step struct {
+		table *TableInfo
+		path  []*TableInfo
+		first *foreignKeyInfo // 経路の最初の外部キー制約
+	}
+	visited := map[*TableInfo]bool{info: true}
+	queue := []step{{table: info, path: []*TableInfo{info}}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, reference := range current.table.References {
+			if visited[reference.parent] {
+				continue
+			}
+			visited[reference.parent] = true
+
+			next := step{table: reference.parent, path: append(append([]*TableInfo{}, current.path...), reference.parent), first: current.first}
+			if next.first == nil {
+				next.first = reference
+			}
+			if reference.parent.EnableRLS == nil {
+				queue = append(queue, next)
+				continue
+			}
+
+			names := make([]string, 0, len(next.path))
+			for _, table := range next.path {
+				names = append(names, table.Name.String())
+			}
+			return append(results, LintResult{
+				Message:   "Table '" + info.Name.String() + "' does not have RLS enabled but references RLS-protected table '" + reference.parent.Name.String() + "' through foreign keys (" + strings.Join(names, " -> ") + "), which leaks the existence of protected rows",
+				TableName: info.Name.String(),
+				RuleID:    "rls-foreign-key-leak",
+				Location:  statementLocation(next.first.statement),
+			})
+		}
+	}
+	return results
+}
+
+// roleInfo はロールの状態を表す構造体
+type roleInfo struct {
+	name       string
+	attributes map[string]*RoleStatement // 有効な属性とそれを設定したステートメント
+}
+
+// rlsBypassAttributes はRLSをバイパスするロールの属性
+var rlsBypassAttributes = []string{"superuser", "bypassrls"}
+
+// applyRole はロールの作成・変更・名前の変更・削除を反映する
+func (c *catalog) applyRole(stmt *RoleStatement) {
+	index := -1
+	for i, role := range c.roles {
+		if role.name == stmt.RoleName {
+			index = i
+		}
+	}
+
+	// 属性はロールに付随するため、名前の変更後も引き継ぐ
+	if stmt.NewName != "" {
+		if index >= 0 {
+			c.roles[index].name = stmt.NewName
+		}
+		return
+	}
+
+	if stmt.Drop {
+		if index >= 0 {
+			c.roles = append(c.roles[:index], c.roles[index+1:]...)
+		}
+		return
+	}
+
+	// 作成済みでないロールのALTER ROLEは入力の外で作成されたロールとして扱う
+	if index < 0 || stmt.Create {
+		if index >= 0 {
+			c.roles = append(c.roles[:index], c.roles[index+1:]...)
+		}
+		c.roles = append(c.roles, &roleInfo{name: stmt.RoleName, attributes: make(map[string]*RoleStatement)})
+		index = len(c.roles) - 1
+	}
+
+	role := c.roles[index]
+	for name, enabled := range stmt.Attributes {
+		if enabled {
+			role.attributes[name] = stmt
+		} else {
+			delete(role.attributes, name)
+		}
+	}
+}
+
+// validateRoles は入力の終端でRLSをバイパスする属性を持つロールを報告する
+func (c *catalog) validateRoles(rules RuleOptions) []LintResult {
+	results := make([]LintResult, 0)
+	for _, role := range c.roles {
+		if slices.Contains(rules.AdminRoles, role.name) {
+			continue
+		}
+		for _, attribute := range rlsBypassAttributes {
+			stmt, ok := role.attributes[attribute]
+			if !ok {
+				continue
+			}
+			results = append(results, LintResult{
+				Message:  "Role '" + role.name + "' is granted " + strings.ToUpper(attribute) + ", which bypasses every RLS policy",
+				RuleID:   "role-bypasses-rls",
+				Severity: SeverityError,
+				Location: statementLocation(stmt.SQLStatement),
+			})
+		}
+	}
+	return results
+}
+
+// applyGrant は権限の付与・取り消しを反映する
+func (c *catalog) applyGrant(stmt *GrantStatement) {
+	switch stmt.Target {
+	case GrantTargetTables:
+		for _, ref := range stmt.Tables {
+			if info := c.find(ref); info != nil {
+				info.Privileges = info.Privileges.apply(stmt)
+			}
+		}
+	case GrantTargetAllTablesInSchema:
+		// ALL TABLES IN SCHEMA は実行時点で存在するテーブル（ビューを含む）のみに適用される
+		for _, info := range c.tables {
+			if slices.Contains(stmt.Schemas, info.Name.Schema) {
+				info.Privileges = info.Privileges.apply(stmt)
+			}
+		}
+	case GrantTargetDefaults:
+		// デフォルト権限は以降に作成されるテーブルに適用される（スキーマの指定がない場合は全スキーマ）
+		schemas := stmt.Schemas
+		if len(schemas) == 0 {
+			schemas = []string{""}
+		}
+		for _, schema := range schemas {
+			c.defaultPrivileges[schema] = c.defaultPrivileges[schema].apply(stmt)
+		}
+	}
+}
+
+// privilegeSet はロールごとに付与されている権限を表す型
+type privilegeSet map[string]map[string]bool
+
+// tablePrivileges はテーブルに付与できる権限（ALL PRIVILEGESの内容）
+var tablePrivileges = []string{"select", "insert", "update", "delete", "truncate", "references", "trigger", "maintain"}
+
+// columnPrivileges は列単位で付与できる権限（ALL (列, ...) の内容）
+var columnPrivileges = []string{"select", "insert", "update", "references"}
+
+// columnPrivilege は列単位の権限を権限の集合に記録する際のキーを返す
+func columnPrivilege(privilege, column string) string {
+	return privilege + "(" + column + ")"
+}
+
+// apply は権限の付与・取り消しを反映した権限の集合を返す（元の集合は変更しない）
+func (s privilegeSet) apply(stmt *GrantStatement) privilegeSet {
+	result := s.merge(nil)
+	for _, role := range stmt.Grantees {
+		privileges := result[role]
+		if privileges == nil {
+			privileges = make(map[string]bool)
+			result[role] = privileges
+		}
+		for _, privilege := range stmt.Privileges {
+			names := []string{privilege}
+			if privilege == "all" {
+				names = tablePrivileges
+			}
+			for _, name := range names {
+				if stmt.IsGrant {
+					privileges[name] = true
+					continue
+				}
+				delete(privileges, name)
+				// テーブル単位の権限の取り消しは同じ権限の列単位の付与も取り消す
+				for key := range privileges {
+					if strings.HasPrefix(key, name+"(") {
+						delete(privileges, key)
+					}
+				}
+			}
+		}
+		for privilege, columns := range stmt.ColumnPrivileges {
+			names := []string{privilege}
+			if privilege == "all" {
+				names = columnPrivileges
+			}
+			for _, name := range names {
+				for _, column := range columns {
+					if stmt.IsGrant {
+						privileges[columnPrivilege(name, column)] = true
+					} else {
+						delete(privileges, columnPrivilege(name, column))
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// merge は2つの権限の集合を合わせた新しい集合を返す
+func (s privilegeSet) merge(other privilegeSet) privilegeSet {
+	result := make(privilegeSet)
+	for _, set := range []privilegeSet{s, other} {
+		for role, privileges := range set {
+			if result[role] == nil {
+				result[role] = make(map[string]bool)
+			}
+			for privilege := range privileges {
+				result[role][privilege] = true
+			}
+		}
+	}
+	return result
+}
+
+// applyRelOptions はパラメータの設定・解除をテーブルの状態に反映する
+func applyRelOptions(info *TableInfo, stmt *RelOptionsStatement) {
+	options := make(map[string]string, len(info.Options)+len(stmt.Options))
+	for name, value := range info.Options {
+		options[name] = value
+	}
+	for name, value := range stmt.Options {
+		if stmt.Reset {
+			delete(options, name)
+		} else {
+			options[name] = value
+		}
+	}
+	info.Options = options
+}
+
+// applyTableDefinition はテーブルの作成を反映する
+func (c *catalog) applyTableDefinition(stmt *TableDefinition) {
+	name := QualifiedName{Schema: stmt.SchemaName, Name: stmt.TableName}
+	if name.Schema == "" {
+		name.Schema = creationSchema(DefaultSearchPath)
+	}
+
+	// CREATE TABLE IF NOT EXISTS は既存のテーブルを変更しない
+	existing, exists := c.tables[name]
+	if exists && stmt.IfNotExists {
+		return
+	}
+
+	var parent *TableInfo
+	if ref := stmt.PartitionOf; ref != nil {
+		parent = c.lookup(*ref, stmt.SQLStatement, "CREATE TABLE ... PARTITION OF")
+	}
+
+	// クエリが参照するテーブルのうち作成済みのものをコピー元として記録する
+	sources := make([]*TableInfo, 0)
+	for _, ref := range stmt.SourceTables {
+		if source := c.find(ref); source != nil {
+			sources = append(sources, source)
+		}
+	}
+
+	// CREATE OR REPLACE VIEW は既存のビューの定義とパラメータを置き換える
+	if exists && stmt.Replace && existing.Definition.Kind == TableKindView {
+		existing.Definition = stmt
+		existing.Sources = sources
+		existing.Options = stmt.Options
+		return
+	}
+
+	// パーティションは親テーブルの列を引き継ぐ
+	columns := stmt.Columns
+	if stmt.PartitionOf != nil {
+		columns = nil
+		if parent != nil && parent.Columns != nil {
+			columns = append(append([]string{}, parent.Columns...), stmt.Columns...)
+		}
+	}
+
+	c.sequence++
+	info := &TableInfo{
+		Name:       name,
+		Definition: stmt,
+		Parent:     parent,
+		Sources:    sources,
+		Options:    stmt.Options,
+		Privileges: c.defaultPrivileges[""].merge(c.defaultPrivileges[name.Schema]),
+		Columns:    columns,
+		sequence:   c.sequence,
+	}
+	c.tables[name] = info
+
+	// 外部キー制約は作成済みのテーブルへの参照のみを記録する（自己参照は対象外）
+	for _, foreignKey := range stmt.ForeignKeys {
+		ref := foreignKey.References
+		if parent := c.find(ref); parent != nil && parent != info {
+			info.References = append(info.References, &foreignKeyInfo{name: foreignKey.ConstraintName, parent: parent, statement: stmt.SQLStatement})
+		}
+	}
+}
+
+// drop はテーブルを削除する
+// パーティションテーブルを削除した場合はPostgreSQLと同様にパーティションも削除される
+func (c *catalog) drop(info *TableInfo) {
+	delete(c.tables, info.Name)
+	for _, table := range c.tables {
+		if table.Parent == info {
+			c.drop(table)
+		}
+	}
+
+	// 削除されたテーブルを参照する外部キー制約も削除される（DROP TABLE ... CASCADE）
+	for _, table := range c.tables {
+		references := make([]*foreignKeyInfo, 0, len(table.References))
+		for _, reference := range table.References {
+			if reference.parent != info {
+				references = append(references, reference)
+			}
+		}
+		table.References = references
+	}
+}
+
+// lookup はステートメントが参照するテーブルを解決する
+// テーブルがまだ作成されていない場合は検証結果を記録してnilを返す
+func (c *catalog) lookup(ref TableReference, stmt SQLStatement, action string) *TableInfo {
+	name := resolveTableName(ref.SchemaName, ref.TableName, ref.SearchPath, c.tables)
+	if info, exists := c.tables[name]; exists {
+		return info
+	}
+
+	if !c.isExcluded(name) {
+		c.results = append(c.results, LintResult{
+			Message:   action + " references table '" + name.String() + "' before it is created",
+			TableName: name.String(),
+			RuleID:    "rls-table-not-created",
+			Location:  statementLocation(stmt),
+		})
+	}
+	return nil
+}
+
+// find はステートメントが参照するテーブルを解決する（存在しない場合はnil）
+func (c *catalog) find(ref TableReference) *TableInfo {
+	return c.tables[resolveTableName(ref.SchemaName, ref.TableName, ref.SearchPath, c.tables)]
+}
+
+// isExcluded は指定されたテーブルが検証対象外かを確認する
+func (c *catalog) isExcluded(name QualifiedName) bool {
+	return isExcludedTable(name, c.excludedTables)
+}
+
+// isExcludedTable はテーブルが検証対象外かを確認する
+// 親テーブルの除外設定を引き継ぐ場合は祖先のテーブルもたどる
+func (c *catalog) isExcludedTable(info *TableInfo) bool {
+	for table := info; table != nil; table = table.Parent {
+		if c.isExcluded(table.Name) {
+			return true
+		}
+		if !c.inheritExclusion {
+			break
+		}
+	}
+	return false
+}
+
+// tableList は現在存在するテーブルを作成順に返す
+func (c *catalog) tableList() []*TableInfo {
+	list := make([]*TableInfo, 0, len(c.tables))
+	for _, info := range c.tables {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].sequence < list[j].sequence
+	})
+	return list
+}
+
+// statementLocation はステートメントの位置情報から検証結果の位置情報を作成する
+// 対象リレーション名の位置がある場合は、その位置からステートメントの末尾までを範囲とする
+func statementLocation(stmt SQLStatement) Location {
+	location := Location{
+		File:      stmt.Filename,
+		Line:      stmt.Line,
+		Column:    stmt.Column,
+		EndLine:   stmt.EndLine,
+		EndColumn: stmt.EndColumn,
+	}
+	if stmt.NameLine > 0 {
+		location.Line, location.Column = stmt.NameLine, stmt.NameColumn
+	}
+	return location
+}
+
+// resolveTableName はテーブル参照をスキーマ修飾名に解決する
+// search_pathが指定されている場合は、定義済みのテーブルが見つかる最初のスキーマに解決する
+func resolveTableName(schema, table string, searchPath []string, tableInfoMap map[QualifiedName]*TableInfo) QualifiedName {
+	if schema != "" && len(searchPath) == 0 {
+		return QualifiedName{Schema: schema, Name: table}
+	}
+	if len(searchPath) == 0 {
+		searchPath = DefaultSearchPath
+	}
+
+	for _, candidate := range searchPath {
+		name := QualifiedName{Schema: candidate, Name: table}
+		if _, exists := tableInfoMap[name]; exists {
+			return name
+		}
+	}
+
+	// 該当するテーブルがない場合はテーブルが作成されるスキーマとみなす
+	if schema == "" {
+		schema = creationSchema(searchPath)
+	}
+	return QualifiedName{Schema: schema, Name: table}
+}
+
+// isExcludedTable は指定されたテーブルが除外リストに含まれているかを確認する
+// 除外リストには "schema.table" 形式のほか、全スキーマに一致するテーブル名のみの指定も使用できる
+func isExcludedTable(name QualifiedName, excludedTables []string) bool {
+	return isExcluded(name.String(), excludedTables) || isExcluded(name.Name, excludedTables)
+}
+
 // isExcluded は指定されたテーブルが除外リストに含まれているかを確認する
 func isExcluded(tableName string, excludedTables []string) bool {
 	for _, excluded := range excludedTables {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,7 +29,7 @@ func TestValidateRLS_MissingRLSEnable(t *testing.T) {
 	result := ValidateRLS(tables, []RLSEnableStatement{}, []PolicyStatement{}, []string{})
 
 	assert.Len(t, result, 1)
-	assert.Equal(t, "accounts", result[0].TableName)
+	assert.Equal(t, "public.accounts", result[0].TableName)
 	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
 	assert.Contains(t, result[0].Message, "does not have RLS enabled")
 }
@@ -53,14 +54,14 @@ func TestValidateRLS_MissingPolicy(t *testing.T) {
 				Line:     2,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
 	result := ValidateRLS(tables, rlsEnables, []PolicyStatement{}, []string{})
 
 	assert.Len(t, result, 1)
-	assert.Equal(t, "accounts", result[0].TableName)
+	assert.Equal(t, "public.accounts", result[0].TableName)
 	assert.Equal(t, "rls-no-policy", result[0].RuleID)
 	assert.Contains(t, result[0].Message, "has no RLS policy configured")
 }
@@ -85,7 +86,7 @@ func TestValidateRLS_Complete(t *testing.T) {
 				Line:     2,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
@@ -96,8 +97,8 @@ func TestValidateRLS_Complete(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "account_policy",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "account_policy",
 		},
 	}
 
@@ -152,7 +153,7 @@ func TestValidateRLS_MultipleTables(t *testing.T) {
 				Line:     2,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
@@ -163,15 +164,15 @@ func TestValidateRLS_MultipleTables(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "account_policy",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "account_policy",
 		},
 	}
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 
 	assert.Len(t, result, 1)
-	assert.Equal(t, "users", result[0].TableName)
+	assert.Equal(t, "public.users", result[0].TableName)
 	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
 }
 
@@ -195,7 +196,7 @@ func TestValidateRLS_MultiplePolicies(t *testing.T) {
 				Line:     2,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
@@ -206,8 +207,8 @@ func TestValidateRLS_MultiplePolicies(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "policy1",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "policy1",
 		},
 		{
 			SQLStatement: SQLStatement{
@@ -215,8 +216,8 @@ func TestValidateRLS_MultiplePolicies(t *testing.T) {
 				Line:     4,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "policy2",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "policy2",
 		},
 	}
 
@@ -310,7 +311,7 @@ func TestValidateRLS_WithFixtures(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
@@ -321,15 +322,15 @@ func TestValidateRLS_WithFixtures(t *testing.T) {
 				Line:     4,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "account_managers",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "account_managers",
 		},
 	}
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 
 	assert.Len(t, result, 1)
-	assert.Equal(t, "users", result[0].TableName)
+	assert.Equal(t, "public.users", result[0].TableName)
 	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
 }
 
@@ -361,7 +362,7 @@ func TestValidateRLS_WithFixtures2(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName: "products",
+			TableReference: TableReference{TableName: "products"},
 		},
 	}
 
@@ -372,15 +373,15 @@ func TestValidateRLS_WithFixtures2(t *testing.T) {
 				Line:     4,
 				Column:   1,
 			},
-			TableName:  "products",
-			PolicyName: "product_policy",
+			TableReference: TableReference{TableName: "products"},
+			PolicyName:     "product_policy",
 		},
 	}
 
 	result := ValidateRLS(tables, rlsEnables, policies, []string{})
 
 	assert.Len(t, result, 1)
-	assert.Equal(t, "orders", result[0].TableName)
+	assert.Equal(t, "public.orders", result[0].TableName)
 	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
 }
 
@@ -412,7 +413,7 @@ func TestValidateRLS_WithExcludedTables(t *testing.T) {
 				Line:     3,
 				Column:   1,
 			},
-			TableName: "accounts",
+			TableReference: TableReference{TableName: "accounts"},
 		},
 	}
 
@@ -423,8 +424,8 @@ func TestValidateRLS_WithExcludedTables(t *testing.T) {
 				Line:     4,
 				Column:   1,
 			},
-			TableName:  "accounts",
-			PolicyName: "account_managers",
+			TableReference: TableReference{TableName: "accounts"},
+			PolicyName:     "account_managers",
 		},
 	}
 
@@ -433,3 +434,1723 @@ func TestValidateRLS_WithExcludedTables(t *testing.T) {
 
 	assert.Empty(t, result)
 }
+
+func TestValidateRLS_SchemaQualified(t *testing.T) {
+	// 別スキーマの同名テーブルが区別されることをテスト
+	tables := []TableDefinition{
+		{
+			SQLStatement: SQLStatement{Filename: "test.sql", Line: 1, Column: 1},
+			TableName:    "users",
+			SchemaName:   "public",
+		},
+		{
+			SQLStatement: SQLStatement{Filename: "test.sql", Line: 2, Column: 1},
+			TableName:    "users",
+			SchemaName:   "auth",
+		},
+	}
+
+	rlsEnables := []RLSEnableStatement{
+		{
+			SQLStatement:   SQLStatement{Filename: "test.sql", Line: 3, Column: 1},
+			TableReference: TableReference{TableName: "users", SchemaName: "auth"},
+		},
+	}
+
+	policies := []PolicyStatement{
+		{
+			SQLStatement:   SQLStatement{Filename: "test.sql", Line: 4, Column: 1},
+			TableReference: TableReference{TableName: "users", SchemaName: "auth"},
+			PolicyName:     "auth_users_policy",
+		},
+	}
+
+	result := ValidateRLS(tables, rlsEnables, policies, []string{})
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "public.users", result[0].TableName)
+	assert.Equal(t, "rls-not-enabled", result[0].RuleID)
+}
+
+func TestValidateRLS_SearchPathResolution(t *testing.T) {
+	// 未修飾の参照がsearch_path上の既存テーブルに解決されることをテスト
+	tables := []TableDefinition{
+		{
+			SQLStatement: SQLStatement{Filename: "test.sql", Line: 1, Column: 1},
+			TableName:    "accounts",
+			SchemaName:   "public",
+		},
+	}
+
+	rlsEnables := []RLSEnableStatement{
+		{
+			SQLStatement:   SQLStatement{Filename: "test.sql", Line: 2, Column: 1},
+			TableReference: TableReference{TableName: "accounts", SchemaName: "tenant", SearchPath: []string{"tenant", "public"}},
+		},
+	}
+
+	policies := []PolicyStatement{
+		{
+			SQLStatement:   SQLStatement{Filename: "test.sql", Line: 3, Column: 1},
+			TableReference: TableReference{TableName: "accounts", SchemaName: "tenant", SearchPath: []string{"tenant", "public"}},
+			PolicyName:     "account_policy",
+		},
+	}
+
+	result := ValidateRLS(tables, rlsEnables, policies, []string{})
+
+	assert.Empty(t, result)
+}
+
+func TestIsExcludedTable(t *testing.T) {
+	testCases := map[string]struct {
+		name           QualifiedName
+		excludedTables []string
+		expected       bool
+	}{
+		"qualified match": {
+			name:           QualifiedName{Schema: "audit", Name: "logs"},
+			excludedTables: []string{"audit.logs"},
+			expected:       true,
+		},
+		"qualified mismatch": {
+			name:           QualifiedName{Schema: "public", Name: "logs"},
+			excludedTables: []string{"audit.logs"},
+			expected:       false,
+		},
+		"unqualified matches any schema": {
+			name:           QualifiedName{Schema: "audit", Name: "logs"},
+			excludedTables: []string{"logs"},
+			expected:       true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isExcludedTable(tc.name, tc.excludedTables))
+		})
+	}
+}
+
+// mustParseStatements はテスト用に複数ファイルのSQLをソース順に解析する
+func mustParseStatements(t *testing.T, sources ...string) []Statement {
+	t.Helper()
+
+	statements := make([]Statement, 0)
+	for i, sql := range sources {
+		parsed, err := ParseStatements(fmt.Sprintf("file%d.sql", i+1), sql, ParseOptions{})
+		assert.NoError(t, err)
+		statements = append(statements, parsed...)
+	}
+	return statements
+}
+
+func TestValidate_StatementOrder(t *testing.T) {
+	testCases := map[string]struct {
+		sources         []string
+		expectedRuleIDs []string
+	}{
+		"enable before create": {
+			sources: []string{`
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE TABLE accounts (id int);`},
+			expectedRuleIDs: []string{"rls-table-not-created", "rls-table-not-created", "rls-not-enabled"},
+		},
+		"policy file before table file": {
+			sources: []string{
+				`ALTER TABLE accounts ENABLE ROW LEVEL SECURITY; CREATE POLICY p ON accounts USING (current_user = 'app');`,
+				`CREATE TABLE accounts (id int);`,
+			},
+			expectedRuleIDs: []string{"rls-table-not-created", "rls-table-not-created", "rls-not-enabled"},
+		},
+		"table file before policy file": {
+			sources: []string{
+				`CREATE TABLE accounts (id int);`,
+				`ALTER TABLE accounts ENABLE ROW LEVEL SECURITY; CREATE POLICY p ON accounts USING (current_user = 'app');`,
+			},
+			expectedRuleIDs: []string{},
+		},
+		"recreated table loses RLS": {
+			sources: []string{`
+CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE TABLE accounts (id int, name text);`},
+			expectedRuleIDs: []string{"rls-not-enabled"},
+		},
+		"create if not exists keeps RLS": {
+			sources: []string{`
+CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE TABLE IF NOT EXISTS accounts (id int);`},
+			expectedRuleIDs: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), []string{}, RuleOptions{})
+
+			ruleIDs := []string{}
+			for _, result := range results {
+				ruleIDs = append(ruleIDs, result.RuleID)
+			}
+			assert.Equal(t, tc.expectedRuleIDs, ruleIDs)
+		})
+	}
+}
+
+func TestValidate_NotCreatedLocation(t *testing.T) {
+	// 未作成のテーブルを参照したステートメントの位置が報告されることをテスト
+	statements := mustParseStatements(t, `CREATE TABLE users (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;`)
+
+	results := Validate(statements, []string{"users"}, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "rls-table-not-created", results[0].RuleID)
+	assert.Equal(t, "public.accounts", results[0].TableName)
+	assert.Equal(t, "file1.sql", results[0].Location.File)
+	assert.Equal(t, 2, results[0].Location.Line)
+}
+
+func TestValidate_ExcludedNotCreated(t *testing.T) {
+	// 除外テーブルへの参照は報告されない
+	statements := mustParseStatements(t, `ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;`)
+
+	results := Validate(statements, []string{"accounts"}, RuleOptions{})
+
+	assert.Empty(t, results)
+}
+
+func TestValidate_MissingOkNotCreated(t *testing.T) {
+	// IF EXISTS が指定されたALTER TABLEの存在しないテーブルへの参照は報告されない
+	statements := mustParseStatements(t, `ALTER TABLE IF EXISTS accounts ENABLE ROW LEVEL SECURITY;
+CREATE TABLE users (id int);
+ALTER TABLE IF EXISTS users ENABLE ROW LEVEL SECURITY;`)
+
+	results := Validate(statements, nil, RuleOptions{})
+
+	assert.Equal(t, []string{"rls-no-policy:public.users"}, ruleIDsOf(results))
+}
+
+func TestValidate_RLSRegressions(t *testing.T) {
+	testCases := map[string]struct {
+		sources          []string
+		expectedRuleIDs  []string
+		expectedLocation int
+	}{
+		"disabled in later migration": {
+			sources: []string{
+				`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');`,
+				`SELECT 1;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;`,
+			},
+			expectedRuleIDs:  []string{"rls-disabled-after-enable"},
+			expectedLocation: 2,
+		},
+		"re-enabled after disable": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');`},
+			expectedRuleIDs: []string{},
+		},
+		"disabled without enable": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;`},
+			expectedRuleIDs:  []string{"rls-not-enabled"},
+			expectedLocation: 1,
+		},
+		"no force after force": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY, FORCE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;`},
+			expectedRuleIDs:  []string{"rls-no-force-after-force"},
+			expectedLocation: 4,
+		},
+		"no force without force": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY, NO FORCE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');`},
+			expectedRuleIDs: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), []string{}, RuleOptions{})
+
+			ruleIDs := []string{}
+			for _, result := range results {
+				ruleIDs = append(ruleIDs, result.RuleID)
+			}
+			assert.Equal(t, tc.expectedRuleIDs, ruleIDs)
+			if len(results) > 0 {
+				assert.Equal(t, tc.expectedLocation, results[0].Location.Line)
+			}
+		})
+	}
+}
+
+// ruleIDsOf は検証結果のルールIDを順に返す
+func ruleIDsOf(results []LintResult) []string {
+	ruleIDs := []string{}
+	for _, result := range results {
+		ruleIDs = append(ruleIDs, result.RuleID+":"+result.TableName)
+	}
+	return ruleIDs
+}
+
+// linesOf は検証結果の行番号を順に返す
+func linesOf(results []LintResult) []int {
+	lines := []int{}
+	for _, result := range results {
+		lines = append(lines, result.Location.Line)
+	}
+	return lines
+}
+
+func TestValidate_RequireForceRLS(t *testing.T) {
+	sql := `CREATE TABLE accounts (id int);
+CREATE TABLE app.orders (id int);
+CREATE TABLE app.items (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE app.orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE app.items ENABLE ROW LEVEL SECURITY, FORCE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE POLICY p ON app.orders USING (current_user = 'app');
+CREATE POLICY p ON app.items USING (current_user = 'app');`
+
+	testCases := map[string]struct {
+		rules           RuleOptions
+		expectedResults []string
+	}{
+		"disabled by default": {
+			rules:           RuleOptions{},
+			expectedResults: []string{},
+		},
+		"required for all tables": {
+			rules:           RuleOptions{RequireForceRLS: true},
+			expectedResults: []string{"rls-not-forced:public.accounts", "rls-not-forced:app.orders"},
+		},
+		"required for selected schemas": {
+			rules:           RuleOptions{RequireForceRLSSchemas: []string{"app"}},
+			expectedResults: []string{"rls-not-forced:app.orders"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, sql), []string{}, tc.rules)
+
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			for _, result := range results {
+				// ENABLE ROW LEVEL SECURITYの位置に報告される
+				assert.Contains(t, []int{4, 5}, result.Location.Line)
+			}
+		})
+	}
+}
+
+func TestValidate_DropAndAlter(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, manager text);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY account_managers ON accounts USING (manager = current_user);
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		expectedResults []string
+	}{
+		"last policy dropped": {
+			sources:         []string{base, `DROP POLICY account_managers ON accounts;`},
+			expectedResults: []string{"rls-no-policy:public.accounts"},
+		},
+		"one of two policies dropped": {
+			sources:         []string{base, `CREATE POLICY p2 ON accounts USING (current_user = 'app'); DROP POLICY account_managers ON accounts;`},
+			expectedResults: []string{},
+		},
+		"policy recreated after drop": {
+			sources:         []string{base, `DROP POLICY account_managers ON accounts; CREATE POLICY account_managers ON accounts USING (current_user = 'app');`},
+			expectedResults: []string{},
+		},
+		"table dropped": {
+			sources:         []string{`CREATE TABLE accounts (id int);`, `DROP TABLE accounts;`},
+			expectedResults: []string{},
+		},
+		"table dropped and recreated": {
+			sources:         []string{base, `DROP TABLE accounts; CREATE TABLE accounts (id int);`},
+			expectedResults: []string{"rls-not-enabled:public.accounts"},
+		},
+		"policy on dropped table": {
+			sources:         []string{base, `DROP TABLE accounts; CREATE POLICY p ON accounts USING (current_user = 'app');`},
+			expectedResults: []string{"rls-table-not-created:public.accounts"},
+		},
+		"drop of unknown objects": {
+			sources:         []string{base, `DROP TABLE IF EXISTS legacy; DROP POLICY IF EXISTS p ON legacy;`},
+			expectedResults: []string{},
+		},
+		"alter policy on unknown table": {
+			sources:         []string{base, `ALTER POLICY p ON legacy USING (current_user = 'app');`},
+			expectedResults: []string{"rls-table-not-created:public.legacy"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), []string{}, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestAlterPolicy(t *testing.T) {
+	// ALTER POLICYで指定された句のみが置き換えられることをテスト
+	statements := mustParseStatements(t, `CREATE TABLE accounts (id int, manager text);
+CREATE POLICY p ON accounts FOR UPDATE TO app USING (manager = current_user) WITH CHECK (true);
+ALTER POLICY p ON accounts USING (id = 1);`)
+
+	policy := statements[1].(*PolicyStatement)
+	altered := alterPolicy(policy, statements[2].(*AlterPolicyStatement))
+
+	assert.Equal(t, "p", altered.PolicyName)
+	assert.Equal(t, 3, altered.Line)
+	assert.Equal(t, "update", altered.Statement.GetCmdName())
+	assert.Len(t, altered.Statement.GetRoles(), 1)
+	assert.NotNil(t, altered.Statement.GetQual().GetAExpr())
+	assert.Equal(t, "id", altered.Statement.GetQual().GetAExpr().GetLexpr().GetColumnRef().GetFields()[0].GetString_().GetSval())
+	assert.True(t, altered.Statement.GetWithCheck().GetAConst().GetBoolval().GetBoolval())
+
+	// 元のポリシーは変更されない
+	assert.Equal(t, "manager", policy.Statement.GetQual().GetAExpr().GetLexpr().GetColumnRef().GetFields()[0].GetString_().GetSval())
+}
+
+func TestValidate_RenameAndSetSchema(t *testing.T) {
+	testCases := map[string]struct {
+		sources         []string
+		expectedResults []string
+	}{
+		"renamed before enable": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts RENAME TO customer_accounts;
+ALTER TABLE customer_accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON customer_accounts USING (current_user = 'app');`},
+			expectedResults: []string{},
+		},
+		"old name after rename": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts RENAME TO customer_accounts;
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;`},
+			expectedResults: []string{"rls-table-not-created:public.accounts", "rls-not-enabled:public.customer_accounts"},
+		},
+		"policies carried over by rename": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');`, `ALTER TABLE accounts RENAME TO customer_accounts;
+DROP POLICY p ON customer_accounts;`},
+			expectedResults: []string{"rls-no-policy:public.customer_accounts"},
+		},
+		"moved to another schema": {
+			sources: []string{`CREATE TABLE x (id int);
+ALTER TABLE x ENABLE ROW LEVEL SECURITY;
+ALTER TABLE x SET SCHEMA private;
+CREATE POLICY p ON private.x USING (current_user = 'app');
+CREATE TABLE y (id int);
+ALTER TABLE y SET SCHEMA private;`},
+			expectedResults: []string{"rls-not-enabled:private.y"},
+		},
+		"renamed policy can be dropped by new name": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+ALTER POLICY p ON accounts RENAME TO q;
+DROP POLICY p ON accounts;`},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), []string{}, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_Partitions(t *testing.T) {
+	parent := `CREATE TABLE events (id int, created_at date) PARTITION BY RANGE (created_at);
+ALTER TABLE events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON events USING (current_user = 'app');
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		excludedTables  []string
+		rules           RuleOptions
+		expectedResults []string
+	}{
+		"partition without own RLS": {
+			sources:         []string{parent, `CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01');`},
+			expectedResults: []string{"rls-partition-not-enabled:public.events_2026"},
+		},
+		"partition with own RLS": {
+			sources: []string{parent, `CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01');
+ALTER TABLE events_2026 ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON events_2026 USING (current_user = 'app');`},
+			expectedResults: []string{},
+		},
+		"parent without RLS": {
+			sources: []string{`CREATE TABLE events (id int, created_at date) PARTITION BY RANGE (created_at);
+CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01');`},
+			expectedResults: []string{"rls-not-enabled:public.events", "rls-not-enabled:public.events_2026"},
+		},
+		"parent created later": {
+			sources: []string{`CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01');`},
+			expectedResults: []string{
+				"rls-table-not-created:public.events",
+				"rls-not-enabled:public.events_2026",
+			},
+		},
+		"parent dropped with partitions": {
+			sources:         []string{parent, `CREATE TABLE events_2026 PARTITION OF events FOR VALUES FROM ('2026-01-01') TO ('2027-01-01'); DROP TABLE events;`},
+			expectedResults: []string{},
+		},
+		"excluded parent": {
+			sources:         []string{`CREATE TABLE events (id int) PARTITION BY LIST (id); CREATE TABLE events_1 PARTITION OF events FOR VALUES IN (1);`},
+			excludedTables:  []string{"events"},
+			expectedResults: []string{"rls-not-enabled:public.events_1"},
+		},
+		"exclusion inherited from parent": {
+			sources:         []string{`CREATE TABLE events (id int) PARTITION BY LIST (id); CREATE TABLE events_1 PARTITION OF events FOR VALUES IN (1) PARTITION BY LIST (id); CREATE TABLE events_1_1 PARTITION OF events_1 FOR VALUES IN (1);`},
+			excludedTables:  []string{"events"},
+			rules:           RuleOptions{InheritExclusion: true},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), tc.excludedTables, tc.rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_DerivedTables(t *testing.T) {
+	protected := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE TABLE countries (id int);
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		excludedTables  []string
+		expectedResults []string
+	}{
+		"create table as from protected table": {
+			sources:         []string{protected, `CREATE TABLE report AS SELECT * FROM accounts;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{"rls-derived-table:public.report"},
+		},
+		"select into from protected table": {
+			sources:         []string{protected, `SELECT a.* INTO tmp FROM countries c JOIN accounts a ON true;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{"rls-derived-table:public.tmp"},
+		},
+		"create table as with no data": {
+			sources:         []string{protected, `CREATE TABLE report AS SELECT * FROM accounts WITH NO DATA;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{"rls-not-enabled:public.report"},
+		},
+		"create table as with own RLS": {
+			sources:         []string{protected, `CREATE TABLE report AS SELECT * FROM accounts; ALTER TABLE report ENABLE ROW LEVEL SECURITY; CREATE POLICY p ON report USING (current_user = 'app');`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{},
+		},
+		"materialized view from protected table": {
+			sources:         []string{protected, `CREATE MATERIALIZED VIEW summary AS SELECT tenant_id, count(*) FROM accounts GROUP BY tenant_id;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{"rls-derived-table:public.summary"},
+		},
+		"materialized view from unprotected table": {
+			sources:         []string{protected, `CREATE MATERIALIZED VIEW country_list AS SELECT * FROM countries;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{},
+		},
+		"materialized view dropped": {
+			sources:         []string{protected, `CREATE MATERIALIZED VIEW summary AS SELECT * FROM accounts; DROP MATERIALIZED VIEW summary;`},
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), tc.excludedTables, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_Views(t *testing.T) {
+	protected := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE TABLE countries (id int);
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		expectedResults []string
+	}{
+		"view over protected table": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts;`},
+			expectedResults: []string{"view-bypasses-rls:public.account_view"},
+		},
+		"view with filter over protected table": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts WHERE tenant_id = 1;`},
+			expectedResults: []string{"view-bypasses-rls:public.account_view", "view-not-security-barrier:public.account_view"},
+		},
+		"security barrier view with filter": {
+			sources:         []string{protected, `CREATE VIEW account_view WITH (security_barrier) AS SELECT * FROM accounts WHERE tenant_id = 1;`},
+			expectedResults: []string{"view-bypasses-rls:public.account_view"},
+		},
+		"security invoker view": {
+			sources:         []string{protected, `CREATE VIEW account_view WITH (security_invoker = true) AS SELECT * FROM accounts WHERE tenant_id = 1;`},
+			expectedResults: []string{},
+		},
+		"security invoker set by ALTER VIEW": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts; ALTER VIEW account_view SET (security_invoker = on);`},
+			expectedResults: []string{},
+		},
+		"security invoker reset by ALTER VIEW": {
+			sources:         []string{protected, `CREATE VIEW account_view WITH (security_invoker) AS SELECT * FROM accounts; ALTER VIEW account_view RESET (security_invoker);`},
+			expectedResults: []string{"view-bypasses-rls:public.account_view"},
+		},
+		"security invoker disabled": {
+			sources:         []string{protected, `CREATE VIEW account_view WITH (security_invoker = false) AS SELECT * FROM accounts;`},
+			expectedResults: []string{"view-bypasses-rls:public.account_view"},
+		},
+		"view replaced with security invoker": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts; CREATE OR REPLACE VIEW account_view WITH (security_invoker) AS SELECT * FROM accounts;`},
+			expectedResults: []string{},
+		},
+		"view over unprotected table": {
+			sources:         []string{protected, `CREATE VIEW country_view AS SELECT * FROM countries WHERE id > 0;`},
+			expectedResults: []string{},
+		},
+		"view over security invoker view": {
+			sources: []string{protected, `CREATE VIEW inner_view WITH (security_invoker) AS SELECT * FROM accounts;
+CREATE VIEW outer_view AS SELECT * FROM inner_view;`},
+			expectedResults: []string{"view-bypasses-rls:public.outer_view"},
+		},
+		"view dropped": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts; DROP VIEW account_view;`},
+			expectedResults: []string{},
+		},
+		"view renamed": {
+			sources:         []string{protected, `CREATE VIEW account_view AS SELECT * FROM accounts; ALTER VIEW account_view RENAME TO accounts_all;`},
+			expectedResults: []string{"view-bypasses-rls:public.accounts_all"},
+		},
+		"materialized view over view": {
+			sources:         []string{protected, `CREATE VIEW account_view WITH (security_invoker) AS SELECT * FROM accounts; CREATE MATERIALIZED VIEW summary AS SELECT * FROM account_view;`},
+			expectedResults: []string{"rls-derived-table:public.summary"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), []string{"countries"}, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_PolicyAlwaysTrue(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		publicTables    []string
+		expectedResults []string
+	}{
+		"using true": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (true);`},
+			expectedResults: []string{"rls-policy-always-true:public.accounts"},
+		},
+		"constant comparison": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (1 = 1);`},
+			expectedResults: []string{"rls-policy-always-true:public.accounts"},
+		},
+		"or with true": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1 OR NOT false);`},
+			expectedResults: []string{"rls-policy-always-true:public.accounts"},
+		},
+		"with check true": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR INSERT WITH CHECK (true);`},
+			expectedResults: []string{"rls-policy-always-true:public.accounts"},
+		},
+		"restrictive policy": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (current_user = 'app'); CREATE POLICY r ON accounts AS RESTRICTIVE USING (true);`},
+			expectedResults: []string{},
+		},
+		"non-constant expression": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1 AND true);`},
+			expectedResults: []string{},
+		},
+		"altered to non-constant expression": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (true); ALTER POLICY p ON accounts USING (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"public table": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (true);`},
+			publicTables:    []string{"accounts"},
+			expectedResults: []string{},
+		},
+		"public table in another schema": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (true);`},
+			publicTables:    []string{"reference.accounts"},
+			expectedResults: []string{"rls-policy-always-true:public.accounts"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, RuleOptions{PublicTables: tc.publicTables})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_PolicyAlwaysTrueLocation(t *testing.T) {
+	// ALTER POLICYで常に真になった場合はALTER POLICY文の位置に報告する
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (id = 1);
+ALTER POLICY p ON accounts USING (true);`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "rls-policy-always-true", results[0].RuleID)
+	assert.Equal(t, 4, results[0].Location.Line)
+	assert.Equal(t, "Permissive policy 'p' on table 'public.accounts' has an always-true USING expression and grants every row", results[0].Message)
+}
+
+func TestValidate_CommandCoverage(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+`
+
+	testCases := map[string]struct {
+		sources          []string
+		requiredCommands []string
+		disabled         bool
+		expectedResults  []string
+		expectedMessage  string
+	}{
+		"policy for all commands": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"select policy only": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (tenant_id = 1);`},
+			expectedResults: []string{"rls-command-uncovered:public.accounts"},
+			expectedMessage: "Table 'public.accounts' has no permissive policy for INSERT, UPDATE, DELETE",
+		},
+		"policies for each command": {
+			sources: []string{base, `CREATE POLICY s ON accounts FOR SELECT USING (tenant_id = 1);
+CREATE POLICY i ON accounts FOR INSERT WITH CHECK (tenant_id = 1);
+CREATE POLICY u ON accounts FOR UPDATE USING (tenant_id = 1);
+CREATE POLICY d ON accounts FOR DELETE USING (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"restrictive policy does not cover": {
+			sources: []string{base, `CREATE POLICY s ON accounts FOR SELECT USING (tenant_id = 1);
+CREATE POLICY r ON accounts AS RESTRICTIVE FOR DELETE USING (tenant_id = 1);`},
+			expectedResults: []string{"rls-only-restrictive:public.accounts", "rls-command-uncovered:public.accounts"},
+			expectedMessage: "Table 'public.accounts' has only restrictive policies for DELETE, which denies every row",
+		},
+		"dropped policy": {
+			sources: []string{base, `CREATE POLICY s ON accounts FOR SELECT USING (tenant_id = 1);
+CREATE POLICY w ON accounts FOR UPDATE USING (tenant_id = 1);
+DROP POLICY w ON accounts;`},
+			requiredCommands: []string{"select", "update"},
+			expectedResults:  []string{"rls-command-uncovered:public.accounts"},
+			expectedMessage:  "Table 'public.accounts' has no permissive policy for UPDATE",
+		},
+		"read-only table": {
+			sources:          []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (tenant_id = 1);`},
+			requiredCommands: []string{"select"},
+			expectedResults:  []string{},
+		},
+		"rule disabled": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (tenant_id = 1);`},
+			disabled:        true,
+			expectedResults: []string{},
+		},
+		"no policy": {
+			sources:         []string{base},
+			expectedResults: []string{"rls-no-policy:public.accounts"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, RuleOptions{CheckCommandCoverage: !tc.disabled, RequiredCommands: tc.requiredCommands})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, results[0].Message)
+			}
+		})
+	}
+}
+
+func TestValidate_PolicyWithCheck(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+`
+
+	testCases := map[string]struct {
+		sources          []string
+		requireWithCheck bool
+		expectedResults  []string
+	}{
+		"insert policy with check": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR INSERT WITH CHECK (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"insert policy without check": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR INSERT;`},
+			expectedResults: []string{"rls-policy-missing-with-check:public.accounts"},
+		},
+		"update policy without check": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR UPDATE USING (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"update policy without check in strict mode": {
+			sources:          []string{base, `CREATE POLICY p ON accounts FOR UPDATE USING (tenant_id = 1);`},
+			requireWithCheck: true,
+			expectedResults:  []string{"rls-policy-missing-with-check:public.accounts"},
+		},
+		"all policy with same check": {
+			sources:          []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1) WITH CHECK (tenant_id  =  1);`},
+			requireWithCheck: true,
+			expectedResults:  []string{},
+		},
+		"all policy with different check": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1) WITH CHECK (tenant_id > 0);`},
+			expectedResults: []string{"rls-policy-missing-with-check:public.accounts"},
+		},
+		"check aligned by ALTER POLICY": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1) WITH CHECK (tenant_id > 0); ALTER POLICY p ON accounts WITH CHECK (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"restrictive insert policy": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1); CREATE POLICY r ON accounts AS RESTRICTIVE FOR INSERT;`},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rules := RuleOptions{RequireWithCheck: tc.requireWithCheck}
+			results := Validate(mustParseStatements(t, tc.sources...), nil, rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_OnlyRestrictive(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		expectedResults []string
+		expectedMessage string
+	}{
+		"restrictive policy only": {
+			sources:         []string{base, `CREATE POLICY r ON accounts AS RESTRICTIVE USING (tenant_id = 1);`},
+			expectedResults: []string{"rls-only-restrictive:public.accounts"},
+			expectedMessage: "Table 'public.accounts' has only restrictive policies for SELECT, INSERT, UPDATE, DELETE, which denies every row",
+		},
+		"restrictive with permissive": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (current_user = 'app'); CREATE POLICY r ON accounts AS RESTRICTIVE USING (tenant_id = 1);`},
+			expectedResults: []string{},
+		},
+		"restrictive for a command without permissive": {
+			sources:         []string{base, `CREATE POLICY p ON accounts FOR SELECT USING (tenant_id = 1); CREATE POLICY r ON accounts AS RESTRICTIVE FOR UPDATE USING (tenant_id = 1);`},
+			expectedResults: []string{"rls-only-restrictive:public.accounts"},
+			expectedMessage: "Table 'public.accounts' has only restrictive policies for UPDATE, which denies every row",
+		},
+		"permissive policy dropped": {
+			sources: []string{base, `CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE POLICY r ON accounts AS RESTRICTIVE USING (tenant_id = 1);
+DROP POLICY p ON accounts;`},
+			expectedResults: []string{"rls-only-restrictive:public.accounts"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, results[0].Message)
+			}
+		})
+	}
+}
+
+func TestValidate_PolicyRoles(t *testing.T) {
+	base := `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+`
+
+	testCases := map[string]struct {
+		sources         []string
+		appRoles        []string
+		sensitiveTables []string
+		expectedResults []string
+		expectedMessage string
+	}{
+		"policy for public applies to application roles": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1);`},
+			appRoles:        []string{"app_user", "reporter"},
+			expectedResults: []string{},
+		},
+		"application role without policy": {
+			sources:         []string{base, `CREATE POLICY p ON accounts TO app_user USING (tenant_id = 1);`},
+			appRoles:        []string{"app_user", "reporter", "batch"},
+			expectedResults: []string{"rls-role-no-policy:public.accounts"},
+			expectedMessage: "Table 'public.accounts' has no permissive policy for application roles reporter, batch",
+		},
+		"restrictive policy does not apply": {
+			sources:         []string{base, `CREATE POLICY p ON accounts TO app_user USING (tenant_id = 1); CREATE POLICY r ON accounts AS RESTRICTIVE TO reporter USING (tenant_id = 1);`},
+			appRoles:        []string{"reporter"},
+			expectedResults: []string{"rls-role-no-policy:public.accounts"},
+		},
+		"roles changed by ALTER POLICY": {
+			sources:         []string{base, `CREATE POLICY p ON accounts TO app_user USING (tenant_id = 1); ALTER POLICY p ON accounts TO reporter;`},
+			appRoles:        []string{"reporter"},
+			expectedResults: []string{},
+		},
+		"public policy on sensitive table": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1);`},
+			sensitiveTables: []string{"accounts"},
+			expectedResults: []string{"rls-policy-public-role:public.accounts"},
+			expectedMessage: "Policy 'p' on sensitive table 'public.accounts' is granted to PUBLIC",
+		},
+		"role policy on sensitive table": {
+			sources:         []string{base, `CREATE POLICY p ON accounts TO app_user USING (tenant_id = 1);`},
+			sensitiveTables: []string{"public.accounts"},
+			expectedResults: []string{},
+		},
+		"restrictive public policy on sensitive table": {
+			sources:         []string{base, `CREATE POLICY p ON accounts TO app_user USING (tenant_id = 1); CREATE POLICY r ON accounts AS RESTRICTIVE USING (tenant_id = 1);`},
+			sensitiveTables: []string{"accounts"},
+			expectedResults: []string{},
+		},
+		"public policy on other table": {
+			sources:         []string{base, `CREATE POLICY p ON accounts USING (tenant_id = 1);`},
+			sensitiveTables: []string{"payments"},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rules := RuleOptions{AppRoles: tc.appRoles, SensitiveTables: tc.sensitiveTables}
+			results := Validate(mustParseStatements(t, tc.sources...), nil, rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, results[0].Message)
+			}
+		})
+	}
+}
+
+func TestValidate_Grants(t *testing.T) {
+	testCases := map[string]struct {
+		sources            []string
+		rules              RuleOptions
+		expectedResults    []string
+		expectedSeverities []string
+	}{
+		"table without grants": {
+			sources:            []string{`CREATE TABLE accounts (id int);`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"granted table without RLS": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT ON accounts TO anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityError},
+		},
+		"granted table with RLS but no policy": {
+			sources:            []string{`CREATE TABLE accounts (id int); ALTER TABLE accounts ENABLE ROW LEVEL SECURITY; GRANT SELECT ON accounts TO anon;`},
+			expectedResults:    []string{"rls-no-policy:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"grant revoked": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT ALL ON accounts TO anon; REVOKE ALL PRIVILEGES ON accounts FROM anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"only non-row privileges": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT ALL ON accounts TO anon; REVOKE SELECT, INSERT, UPDATE, DELETE ON accounts FROM anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"column grant": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT (id) ON accounts TO anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityError},
+		},
+		"column grant revoked": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT (id) ON accounts TO anon; REVOKE SELECT (id) ON accounts FROM anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"column revoke keeps table grant": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT ON accounts TO anon; REVOKE SELECT (id) ON accounts FROM anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityError},
+		},
+		"table revoke removes column grants": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT ALL (id) ON accounts TO anon; REVOKE ALL ON accounts FROM anon;`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"all tables in schema": {
+			sources: []string{`CREATE TABLE accounts (id int);
+GRANT SELECT ON ALL TABLES IN SCHEMA public TO app_user;
+CREATE TABLE logs (id int);`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts", "rls-not-enabled:public.logs"},
+			expectedSeverities: []string{SeverityError, SeverityWarning},
+		},
+		"default privileges": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO app_user;
+CREATE TABLE logs (id int);
+CREATE TABLE app.items (id int);`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts", "rls-not-enabled:public.logs", "rls-not-enabled:app.items"},
+			expectedSeverities: []string{SeverityWarning, SeverityError, SeverityWarning},
+		},
+		"default privileges revoked": {
+			sources: []string{`ALTER DEFAULT PRIVILEGES GRANT SELECT ON TABLES TO app_user;
+ALTER DEFAULT PRIVILEGES REVOKE SELECT ON TABLES FROM app_user;
+CREATE TABLE accounts (id int);`},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"granted view": {
+			sources: []string{`CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (current_user = 'app');
+CREATE VIEW account_view AS SELECT * FROM accounts;
+GRANT SELECT ON account_view TO anon;`},
+			expectedResults:    []string{"view-bypasses-rls:public.account_view"},
+			expectedSeverities: []string{SeverityError},
+		},
+		"grant to role other than application roles": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT ON accounts TO admin;`},
+			rules:              RuleOptions{AppRoles: []string{"app_user"}},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityWarning},
+		},
+		"grant to public with application roles": {
+			sources:            []string{`CREATE TABLE accounts (id int); GRANT SELECT ON accounts TO PUBLIC;`},
+			rules:              RuleOptions{AppRoles: []string{"app_user"}},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityError},
+		},
+		"only granted tables": {
+			sources:            []string{`CREATE TABLE accounts (id int); CREATE TABLE logs (id int); GRANT SELECT ON accounts TO anon;`},
+			rules:              RuleOptions{OnlyGrantedTables: true},
+			expectedResults:    []string{"rls-not-enabled:public.accounts"},
+			expectedSeverities: []string{SeverityError},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, tc.rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+
+			severities := make([]string, 0, len(results))
+			for _, result := range results {
+				severities = append(severities, result.Severity)
+			}
+			assert.Equal(t, tc.expectedSeverities, severities)
+		})
+	}
+}
+
+func TestValidate_GrantMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int); GRANT SELECT ON accounts TO anon, PUBLIC;`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Table 'public.accounts' does not have RLS enabled (privileges granted to anon, public)", results[0].Message)
+}
+
+func TestValidate_Roles(t *testing.T) {
+	testCases := map[string]struct {
+		sources         []string
+		adminRoles      []string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"role with bypassrls": {
+			sources:         []string{"CREATE ROLE app LOGIN;\nCREATE ROLE service WITH LOGIN BYPASSRLS;"},
+			expectedResults: []string{"role-bypasses-rls:"},
+			expectedLines:   []int{2},
+		},
+		"role altered to superuser": {
+			sources:         []string{"CREATE ROLE app LOGIN;", "ALTER ROLE app SUPERUSER;"},
+			expectedResults: []string{"role-bypasses-rls:"},
+			expectedLines:   []int{1},
+		},
+		"role with both attributes": {
+			sources:         []string{"CREATE USER admin SUPERUSER BYPASSRLS;"},
+			expectedResults: []string{"role-bypasses-rls:", "role-bypasses-rls:"},
+			expectedLines:   []int{1, 1},
+		},
+		"attribute removed": {
+			sources:         []string{"CREATE ROLE service BYPASSRLS;\nALTER ROLE service NOBYPASSRLS;"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"role recreated": {
+			sources:         []string{"CREATE ROLE service BYPASSRLS;\nDROP ROLE service;\nCREATE ROLE service;"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"role dropped": {
+			sources:         []string{"CREATE ROLE service BYPASSRLS;\nDROP ROLE IF EXISTS service;"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"admin role": {
+			sources:         []string{"CREATE ROLE migrator SUPERUSER;"},
+			adminRoles:      []string{"migrator"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"role renamed to admin role": {
+			sources:         []string{"CREATE ROLE s BYPASSRLS;\nALTER ROLE s RENAME TO admin;"},
+			adminRoles:      []string{"admin"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"admin role renamed": {
+			sources:         []string{"CREATE ROLE admin BYPASSRLS;", "ALTER ROLE admin RENAME TO s;"},
+			adminRoles:      []string{"admin"},
+			expectedResults: []string{"role-bypasses-rls:"},
+			expectedLines:   []int{1},
+		},
+		"renamed role dropped": {
+			sources:         []string{"CREATE ROLE s BYPASSRLS;\nALTER ROLE s RENAME TO worker;\nDROP ROLE worker;"},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, RuleOptions{AdminRoles: tc.adminRoles})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+			for _, result := range results {
+				assert.Equal(t, SeverityError, result.Severity)
+			}
+		})
+	}
+}
+
+func TestValidate_RoleMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `ALTER ROLE app WITH BYPASSRLS;`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Role 'app' is granted BYPASSRLS, which bypasses every RLS policy", results[0].Message)
+	assert.Equal(t, "", results[0].TableName)
+}
+
+func TestValidate_RenamedRoleMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, "CREATE ROLE s BYPASSRLS;\nALTER ROLE s RENAME TO worker;"), nil, RuleOptions{})
+
+	// 位置情報は属性を付与したステートメントを指し、メッセージは変更後の名前を使う
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Role 'worker' is granted BYPASSRLS, which bypasses every RLS policy", results[0].Message)
+	assert.Equal(t, 1, results[0].Location.Line)
+}
+
+func TestValidate_SecurityDefinerFunctions(t *testing.T) {
+	const table = `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant ON accounts USING (tenant_id = NULLIF(current_setting('app.tenant', true), '')::int);
+`
+	testCases := map[string]struct {
+		sources         []string
+		rules           RuleOptions
+		expectedResults []string
+	}{
+		"definer without search_path": {
+			sources:         []string{table + `CREATE FUNCTION f(t int) RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER AS $$ SELECT * FROM accounts WHERE tenant_id = t $$;`},
+			expectedResults: []string{"security-definer-bypasses-rls:public.accounts"},
+		},
+		"definer without filter": {
+			sources:         []string{table + `CREATE FUNCTION f() RETURNS void LANGUAGE plpgsql SECURITY DEFINER SET search_path = public AS $$ BEGIN DELETE FROM accounts; END $$;`},
+			expectedResults: []string{"security-definer-bypasses-rls:public.accounts"},
+		},
+		"definer with search_path and filter": {
+			sources:         []string{table + `CREATE FUNCTION f(t int) RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ SELECT * FROM accounts WHERE tenant_id = t $$;`},
+			expectedResults: []string{},
+		},
+		"filter on tenant column": {
+			sources:         []string{table + `CREATE FUNCTION f(t int) RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ SELECT * FROM accounts WHERE tenant_id = t $$;`},
+			rules:           RuleOptions{TenantColumn: "tenant_id"},
+			expectedResults: []string{},
+		},
+		"filter without tenant column": {
+			sources:         []string{table + `CREATE FUNCTION f(i int) RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ SELECT * FROM accounts WHERE id = i $$;`},
+			rules:           RuleOptions{TenantColumn: "tenant_id"},
+			expectedResults: []string{"security-definer-bypasses-rls:public.accounts"},
+		},
+		"insert with tenant column": {
+			sources:         []string{table + `CREATE FUNCTION f(t int) RETURNS void LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ INSERT INTO accounts (id, tenant_id) VALUES (1, t) $$;`},
+			rules:           RuleOptions{TenantColumn: "tenant_id"},
+			expectedResults: []string{},
+		},
+		"security invoker": {
+			sources:         []string{table + `CREATE FUNCTION f() RETURNS SETOF accounts LANGUAGE sql AS $$ SELECT * FROM accounts $$;`},
+			expectedResults: []string{},
+		},
+		"table without rls": {
+			sources:         []string{`CREATE TABLE logs (id int); CREATE FUNCTION f() RETURNS SETOF logs LANGUAGE sql SECURITY DEFINER AS $$ SELECT * FROM logs $$;`},
+			expectedResults: []string{"rls-not-enabled:public.logs"},
+		},
+		"function created before table": {
+			sources:         []string{`CREATE FUNCTION f() RETURNS bigint SECURITY DEFINER RETURN (SELECT count(*) FROM accounts);`, table},
+			expectedResults: []string{"security-definer-bypasses-rls:public.accounts"},
+		},
+		"function replaced": {
+			sources: []string{table + `CREATE FUNCTION f() RETURNS bigint LANGUAGE sql SECURITY DEFINER AS $$ SELECT count(*) FROM accounts $$;
+CREATE OR REPLACE FUNCTION f() RETURNS bigint LANGUAGE sql AS $$ SELECT count(*) FROM accounts $$;`},
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sources...), nil, tc.rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_SecurityDefinerMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant ON accounts USING (id = current_setting('app.id', true));
+CREATE FUNCTION auth.all_accounts() RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER AS $$ SELECT * FROM accounts $$;`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "SECURITY DEFINER function 'auth.all_accounts' accesses RLS-protected table 'public.accounts' without SET search_path and without a WHERE clause", results[0].Message)
+	assert.Equal(t, 4, results[0].Location.Line)
+
+	results = Validate(mustParseStatements(t, `CREATE TABLE accounts (id int, tenant_id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tenant ON accounts USING (tenant_id = NULLIF(current_setting('app.tenant', true), '')::int);
+CREATE FUNCTION f(i int) RETURNS SETOF accounts LANGUAGE sql SECURITY DEFINER SET search_path = public AS $$ SELECT * FROM accounts WHERE id = i $$;`), nil, RuleOptions{TenantColumn: "tenant_id"})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "SECURITY DEFINER function 'public.f' accesses RLS-protected table 'public.accounts' without filtering on tenant column 'tenant_id'", results[0].Message)
+}
+
+func TestValidate_SessionSettings(t *testing.T) {
+	testCases := map[string]struct {
+		sql             string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"row_security off": {
+			sql:             "SET search_path = app;\nSET row_security = off;",
+			expectedResults: []string{"session-row-security-off:"},
+			expectedLines:   []int{2},
+		},
+		"row_security off in various forms": {
+			sql:             "SET LOCAL row_security TO false;\nSET row_security = 0;\nSELECT set_config('row_security', 'off', true);",
+			expectedResults: []string{"session-row-security-off:", "session-row-security-off:", "session-row-security-off:"},
+			expectedLines:   []int{1, 2, 3},
+		},
+		"row_security on and reset": {
+			sql:             "SET row_security = on;\nRESET row_security;",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"role switches": {
+			sql:             "SET ROLE admin;\nSET SESSION AUTHORIZATION 'bob';\nSELECT set_config('role', 'admin', false);",
+			expectedResults: []string{"session-role-switch:", "session-role-switch:", "session-role-switch:"},
+			expectedLines:   []int{1, 2, 3},
+		},
+		"role reset": {
+			sql:             "RESET ROLE;\nSET ROLE NONE;\nSET SESSION AUTHORIZATION DEFAULT;\nRESET SESSION AUTHORIZATION;",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sql), nil, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+			for _, result := range results {
+				assert.Equal(t, SeverityWarning, result.Severity)
+			}
+		})
+	}
+}
+
+func TestValidate_SessionSettingMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `SET LOCAL ROLE admin;`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "SET ROLE switches to role 'admin' for the rest of the transaction; later statements run with that role's privileges and policies", results[0].Message)
+}
+
+func TestValidate_PolicyUnknownColumn(t *testing.T) {
+	const table = "CREATE TABLE accounts (id int, tenant_id int);\nALTER TABLE accounts ENABLE ROW LEVEL SECURITY;\n"
+	testCases := map[string]struct {
+		sql             string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"known columns": {
+			sql:             table + "CREATE POLICY p ON accounts USING (accounts.tenant_id = 1 AND id > 0 AND ctid IS NOT NULL);",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"unknown column": {
+			sql:             table + "CREATE POLICY p ON accounts USING (manager = current_user);",
+			expectedResults: []string{"policy-unknown-column:public.accounts"},
+			expectedLines:   []int{3},
+		},
+		"unknown column in with check": {
+			sql:             table + "CREATE POLICY p ON accounts FOR INSERT WITH CHECK (public.accounts.owner = current_user);",
+			expectedResults: []string{"policy-unknown-column:public.accounts"},
+			expectedLines:   []int{3},
+		},
+		"column added before policy": {
+			sql:             table + "ALTER TABLE accounts ADD COLUMN manager text;\nCREATE POLICY p ON accounts USING (manager = current_user);",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"column dropped before policy": {
+			sql:             table + "ALTER TABLE accounts DROP COLUMN tenant_id;\nCREATE POLICY p ON accounts USING (tenant_id = 1);",
+			expectedResults: []string{"policy-unknown-column:public.accounts"},
+			expectedLines:   []int{4},
+		},
+		"column renamed before policy": {
+			sql:             table + "ALTER TABLE accounts RENAME COLUMN tenant_id TO org_id;\nCREATE POLICY p ON accounts USING (tenant_id = 1);",
+			expectedResults: []string{"policy-unknown-column:public.accounts"},
+			expectedLines:   []int{4},
+		},
+		"altered policy": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id = 1);\nALTER POLICY p ON accounts USING (org_id = 1);",
+			expectedResults: []string{"policy-unknown-column:public.accounts"},
+			expectedLines:   []int{4},
+		},
+		"subquery columns are not checked": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id IN (SELECT org_id FROM members));",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"whole row reference": {
+			sql:             table + "CREATE POLICY p ON accounts USING (check_row(accounts));",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"partition inherits parent columns": {
+			sql:             "CREATE TABLE events (id int, tenant_id int) PARTITION BY RANGE (id);\nCREATE TABLE events_1 PARTITION OF events FOR VALUES FROM (0) TO (10);\nALTER TABLE events_1 ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON events_1 USING (tenant_id = 1 AND owner = 'a');",
+			expectedResults: []string{"policy-unknown-column:public.events_1", "rls-not-enabled:public.events"},
+			expectedLines:   []int{4, 1},
+		},
+		"columns from like are unknown": {
+			sql:             "CREATE TABLE base (id int);\nALTER TABLE base ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON base USING (id = 1);\nCREATE TABLE copy (LIKE base);\nALTER TABLE copy ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON copy USING (anything = 1);",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sql), nil, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+		})
+	}
+}
+
+func TestValidate_PolicyUnknownColumnMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY managers ON accounts USING (manager = current_user AND manager IS NOT NULL);`), nil, RuleOptions{})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Policy 'managers' on table 'public.accounts' references unknown column 'manager'", results[0].Message)
+}
+
+func TestValidate_RenamedColumnRevalidated(t *testing.T) {
+	statements := mustParseStatements(t, `CREATE TABLE accounts (id int, owner text);
+ALTER TABLE accounts RENAME COLUMN owner TO manager;
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (manager = current_user);`)
+
+	// 同じステートメントを繰り返し検証しても、列の名前の変更が解析結果に残らない
+	for i := 0; i < 2; i++ {
+		assert.Empty(t, Validate(statements, nil, RuleOptions{}))
+	}
+	assert.Equal(t, []string{"id", "owner"}, statements[0].(*TableDefinition).Columns)
+}
+
+func TestValidate_TenantColumn(t *testing.T) {
+	const expr = "NULLIF(current_setting('app.tenant_id', true), '')::int"
+	const table = "CREATE TABLE accounts (id int, tenant_id int);\nALTER TABLE accounts ENABLE ROW LEVEL SECURITY;\n"
+	testCases := map[string]struct {
+		sql             string
+		tenantExpr      string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"matching policy": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id = " + expr + ") WITH CHECK (tenant_id = " + expr + ");",
+			tenantExpr:      expr,
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"qualified column on the right": {
+			sql:             table + "CREATE POLICY p ON accounts FOR INSERT WITH CHECK (" + expr + " = accounts.tenant_id);",
+			tenantExpr:      expr,
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"additional conditions": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id = " + expr + " AND id > 0);",
+			tenantExpr:      expr,
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"table without tenant column": {
+			sql:             "CREATE TABLE logs (id int);\nALTER TABLE logs ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON logs USING (id = 1);",
+			tenantExpr:      expr,
+			expectedResults: []string{"rls-tenant-column:public.logs"},
+			expectedLines:   []int{1},
+		},
+		"tenant column added later": {
+			sql:             "CREATE TABLE logs (id int);\nALTER TABLE logs ADD COLUMN tenant_id int;\nALTER TABLE logs ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON logs USING (tenant_id = " + expr + ");",
+			tenantExpr:      expr,
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"policy not referencing column": {
+			sql:             table + "CREATE POLICY p ON accounts USING (id = 1);",
+			tenantExpr:      expr,
+			expectedResults: []string{"rls-tenant-column:public.accounts"},
+			expectedLines:   []int{3},
+		},
+		"policy comparing to another expression": {
+			sql:             table + "CREATE POLICY p ON accounts FOR SELECT USING (tenant_id = " + expr + ");\nCREATE POLICY q ON accounts FOR INSERT WITH CHECK (tenant_id = 1);",
+			tenantExpr:      expr,
+			expectedResults: []string{"rls-tenant-column:public.accounts"},
+			expectedLines:   []int{4},
+		},
+		"comparison only in subquery": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id IN (SELECT tenant_id FROM members WHERE tenant_id = " + expr + "));",
+			tenantExpr:      expr,
+			expectedResults: []string{"rls-tenant-column:public.accounts"},
+			expectedLines:   []int{3},
+		},
+		"no expression configured": {
+			sql:             table + "CREATE POLICY p ON accounts USING (tenant_id = 1);\nCREATE POLICY q ON accounts USING (id = 1);",
+			expectedResults: []string{"rls-tenant-column:public.accounts"},
+			expectedLines:   []int{4},
+		},
+		"rls not enabled": {
+			sql:             "CREATE TABLE logs (id int);",
+			tenantExpr:      expr,
+			expectedResults: []string{"rls-not-enabled:public.logs"},
+			expectedLines:   []int{1},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rules := RuleOptions{TenantColumn: "tenant_id", TenantExpression: tc.tenantExpr}
+			results := Validate(mustParseStatements(t, tc.sql), nil, rules)
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+		})
+	}
+}
+
+func TestValidate_TenantColumnMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int, tenant_id uuid);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (tenant_id = NULLIF(current_setting('app.tenant', true), '')::uuid);`), nil, RuleOptions{
+		TenantColumn:     "tenant_id",
+		TenantExpression: "NULLIF(current_setting('app.tenant_id', true), '')::uuid",
+	})
+
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Policy 'p' on table 'public.accounts' does not compare tenant column 'tenant_id' to NULLIF(current_setting('app.tenant_id', true), '')::uuid in USING", results[0].Message)
+}
+
+func TestValidate_PolicyCurrentSetting(t *testing.T) {
+	const table = "CREATE TABLE accounts (id int, tenant_id uuid, name text);\nALTER TABLE accounts ENABLE ROW LEVEL SECURITY;\n"
+	testCases := map[string]struct {
+		using           string
+		expectedResults []string
+	}{
+		"safe form": {
+			using:           "tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid",
+			expectedResults: []string{},
+		},
+		"missing_ok without cast": {
+			using:           "name = current_setting('app.name', true)",
+			expectedResults: []string{},
+		},
+		"without missing_ok": {
+			using:           "name = current_setting('app.name')",
+			expectedResults: []string{"policy-current-setting-unsafe:public.accounts"},
+		},
+		"explicit false missing_ok": {
+			using:           "name = pg_catalog.current_setting('app.name', false)",
+			expectedResults: []string{"policy-current-setting-unsafe:public.accounts"},
+		},
+		"cast without nullif": {
+			using:           "tenant_id = current_setting('app.tenant_id', true)::uuid",
+			expectedResults: []string{"policy-current-setting-unsafe:public.accounts"},
+		},
+		"cast to text": {
+			using:           "name = current_setting('app.name', true)::text",
+			expectedResults: []string{},
+		},
+		"both problems in subquery": {
+			using:           "id IN (SELECT account_id FROM members WHERE tenant_id = current_setting('app.tenant_id')::uuid)",
+			expectedResults: []string{"policy-current-setting-unsafe:public.accounts"},
+		},
+		"non-constant missing_ok": {
+			using:           "name = current_setting('app.name', is_lenient())",
+			expectedResults: []string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, table+"CREATE POLICY p ON accounts USING ("+tc.using+");"), nil, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+		})
+	}
+}
+
+func TestValidate_PolicyCurrentSettingMessage(t *testing.T) {
+	testCases := map[string]struct {
+		expr            string
+		expectedMessage string
+	}{
+		"without missing_ok": {
+			expr:            "name = current_setting('app.name')",
+			expectedMessage: "Policy 'p' on table 'public.accounts' calls current_setting('app.name') without missing_ok, which raises an error when the setting is unset or empty; use current_setting('app.name', true)",
+		},
+		"unsafe cast": {
+			expr:            "tenant_id = current_setting('app.tenant_id', true)::uuid",
+			expectedMessage: "Policy 'p' on table 'public.accounts' casts current_setting('app.tenant_id') to uuid without NULLIF, which raises an error when the setting is unset or empty; use NULLIF(current_setting('app.tenant_id', true), '')::uuid",
+		},
+		"both": {
+			expr:            "tenant_id = current_setting('app.tenant_id')::uuid",
+			expectedMessage: "Policy 'p' on table 'public.accounts' calls current_setting('app.tenant_id') without missing_ok and casts it to uuid without NULLIF, which raises an error when the setting is unset or empty; use NULLIF(current_setting('app.tenant_id', true), '')::uuid",
+		},
+		"cast spelled as written": {
+			expr:            "id = current_setting('app.id', true)::int",
+			expectedMessage: "Policy 'p' on table 'public.accounts' casts current_setting('app.id') to int without NULLIF, which raises an error when the setting is unset or empty; use NULLIF(current_setting('app.id', true), '')::int",
+		},
+		"cast to qualified type": {
+			expr:            "id = current_setting('app.id', true)::pg_catalog.int8",
+			expectedMessage: "Policy 'p' on table 'public.accounts' casts current_setting('app.id') to bigint without NULLIF, which raises an error when the setting is unset or empty; use NULLIF(current_setting('app.id', true), '')::bigint",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// USINGとWITH CHECKの同じ問題は1つにまとめて報告する
+			results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int, tenant_id uuid, name text);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (`+tc.expr+`) WITH CHECK (`+tc.expr+`);`), nil, RuleOptions{})
+
+			assert.Len(t, results, 1)
+			assert.Equal(t, tc.expectedMessage, results[0].Message)
+			assert.Equal(t, 3, results[0].Location.Line)
+		})
+	}
+}
+
+func TestValidate_ForeignKeyLeak(t *testing.T) {
+	const accounts = "CREATE TABLE accounts (id int PRIMARY KEY);\nALTER TABLE accounts ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON accounts USING (id = 1);\n"
+	testCases := map[string]struct {
+		sql             string
+		excludedTables  []string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"child without rls": {
+			sql:             accounts + "CREATE TABLE orders (id int, account_id int REFERENCES accounts);",
+			excludedTables:  []string{},
+			expectedResults: []string{"rls-not-enabled:public.orders", "rls-foreign-key-leak:public.orders"},
+			expectedLines:   []int{4, 4},
+		},
+		"child with rls": {
+			sql:             accounts + "CREATE TABLE orders (id int, account_id int REFERENCES accounts);\nALTER TABLE orders ENABLE ROW LEVEL SECURITY;\nCREATE POLICY p ON orders USING (id = 1);",
+			excludedTables:  []string{},
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"constraint added later": {
+			sql:             accounts + "CREATE TABLE orders (id int, account_id int);\nALTER TABLE orders ADD CONSTRAINT orders_account FOREIGN KEY (account_id) REFERENCES accounts;",
+			excludedTables:  []string{},
+			expectedResults: []string{"rls-not-enabled:public.orders", "rls-foreign-key-leak:public.orders"},
+			expectedLines:   []int{4, 5},
+		},
+		"constraint dropped": {
+			sql:             accounts + "CREATE TABLE orders (id int, account_id int REFERENCES accounts);\nALTER TABLE orders DROP CONSTRAINT orders_account_id_fkey;",
+			excludedTables:  []string{},
+			expectedResults: []string{"rls-not-enabled:public.orders"},
+			expectedLines:   []int{4},
+		},
+		"transitive path": {
+			sql:             accounts + "CREATE TABLE invoices (id int PRIMARY KEY, account_id int REFERENCES accounts);\nCREATE TABLE lines (invoice_id int REFERENCES invoices);",
+			excludedTables:  []string{"invoices"},
+			expectedResults: []string{"rls-not-enabled:public.lines", "rls-foreign-key-leak:public.lines"},
+			expectedLines:   []int{5, 5},
+		},
+		"parent dropped": {
+			sql:             accounts + "CREATE TABLE orders (id int, account_id int REFERENCES accounts);\nDROP TABLE accounts CASCADE;",
+			excludedTables:  []string{},
+			expectedResults: []string{"rls-not-enabled:public.orders"},
+			expectedLines:   []int{4},
+		},
+		"parent without rls": {
+			sql:             "CREATE TABLE countries (code text PRIMARY KEY);\nCREATE TABLE addresses (country text REFERENCES countries);",
+			excludedTables:  []string{"countries"},
+			expectedResults: []string{"rls-not-enabled:public.addresses"},
+			expectedLines:   []int{2},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sql), tc.excludedTables, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+		})
+	}
+}
+
+func TestValidate_ForeignKeyLeakMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE accounts (id int PRIMARY KEY);
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY p ON accounts USING (id = 1);
+CREATE TABLE invoices (id int PRIMARY KEY, account_id int REFERENCES accounts);
+CREATE TABLE lines (invoice_id int);
+ALTER TABLE lines ADD FOREIGN KEY (invoice_id) REFERENCES invoices;`), []string{"invoices"}, RuleOptions{})
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Table 'public.lines' does not have RLS enabled but references RLS-protected table 'public.accounts' through foreign keys (public.lines -> public.invoices -> public.accounts), which leaks the existence of protected rows", results[1].Message)
+	assert.Equal(t, 6, results[1].Location.Line)
+}
+
+func TestValidate_PolicyRecursion(t *testing.T) {
+	const tables = "CREATE TABLE members (team_id int, user_name text);\nCREATE TABLE teams (id int, owner text);\nALTER TABLE members ENABLE ROW LEVEL SECURITY;\nALTER TABLE teams ENABLE ROW LEVEL SECURITY;\n"
+	testCases := map[string]struct {
+		sql             string
+		expectedResults []string
+		expectedLines   []int
+	}{
+		"mutual recursion": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams USING (id IN (SELECT team_id FROM members WHERE user_name = current_user));",
+			expectedResults: []string{"rls-policy-recursion:public.members"},
+			expectedLines:   []int{5},
+		},
+		"self recursion": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT team_id FROM members WHERE user_name = current_user));\nCREATE POLICY t ON teams USING (owner = current_user);",
+			expectedResults: []string{"rls-policy-recursion:public.members"},
+			expectedLines:   []int{5},
+		},
+		"no cycle": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams USING (owner = current_user);",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"recursion on update only": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams FOR SELECT USING (owner = current_user);\nCREATE POLICY u ON teams FOR UPDATE USING (id IN (SELECT team_id FROM members));",
+			expectedResults: []string{"rls-policy-recursion:public.teams"},
+			expectedLines:   []int{7},
+		},
+		"referenced policies not applied to select": {
+			sql:             tables + "CREATE POLICY m ON members FOR DELETE USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams FOR SELECT USING (owner = current_user);\nCREATE POLICY u ON teams FOR UPDATE USING (id IN (SELECT team_id FROM members));",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"cycle through with check": {
+			sql:             tables + "CREATE POLICY t ON teams FOR INSERT WITH CHECK (id IN (SELECT team_id FROM members));\nCREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));",
+			expectedResults: []string{"rls-policy-recursion:public.teams"},
+			expectedLines:   []int{5},
+		},
+		"cycle removed by alter policy": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams USING (id IN (SELECT team_id FROM members));\nALTER POLICY t ON teams USING (owner = current_user);",
+			expectedResults: []string{},
+			expectedLines:   []int{},
+		},
+		"rls disabled on referenced table": {
+			sql:             tables + "CREATE POLICY m ON members USING (team_id IN (SELECT id FROM teams));\nCREATE POLICY t ON teams USING (id IN (SELECT team_id FROM members));\nALTER TABLE teams DISABLE ROW LEVEL SECURITY;",
+			expectedResults: []string{"rls-disabled-after-enable:public.teams"},
+			expectedLines:   []int{7},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			results := Validate(mustParseStatements(t, tc.sql), nil, RuleOptions{})
+			assert.Equal(t, tc.expectedResults, ruleIDsOf(results))
+			assert.Equal(t, tc.expectedLines, linesOf(results))
+		})
+	}
+}
+
+func TestValidate_PolicyRecursionMessage(t *testing.T) {
+	results := Validate(mustParseStatements(t, `CREATE TABLE members (team_id int);
+CREATE TABLE teams (id int, org_id int);
+CREATE TABLE orgs (id int);
+ALTER TABLE members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orgs ENABLE ROW LEVEL SECURITY;
+CREATE POLICY members_by_team ON members USING (team_id IN (SELECT id FROM teams));
+CREATE POLICY teams_by_org ON teams USING (org_id IN (SELECT id FROM orgs));
+CREATE POLICY orgs_by_member ON orgs USING (EXISTS (SELECT 1 FROM members));`), nil, RuleOptions{})
+
+	// 同じ循環は最初に作成されたテーブルのポリシーの位置に一度だけ報告する
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Policy subqueries form a cycle that causes infinite recursion at runtime: 'members_by_team' on public.members -> 'teams_by_org' on public.teams -> 'orgs_by_member' on public.orgs -> public.members", results[0].Message)
+	assert.Equal(t, 7, results[0].Location.Line)
+}